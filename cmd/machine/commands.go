@@ -0,0 +1,642 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ring-network/pkg/bench"
+	"ring-network/pkg/config"
+	"ring-network/pkg/discovery"
+	"ring-network/pkg/election"
+	"ring-network/pkg/kvstore"
+	"ring-network/pkg/message"
+	"ring-network/pkg/metrics"
+	"ring-network/pkg/network"
+	"ring-network/pkg/timesync"
+)
+
+// commandLine executa os comandos da interface sobre a máquina e os serviços construídos sobre ela
+// A saída dos comandos vai para out, o terminal ou o painel de saída do modo --tui
+type commandLine struct {
+	cfg       *config.Config
+	machine   *network.Machine
+	store     *kvstore.Store
+	elections *election.Service
+	clocks    *timesync.Service
+	census    *discovery.Service
+	out       io.Writer
+	stopWatch func() // Cancela o acompanhamento de eventos do comando watch
+}
+
+// printHelp exibe a lista de comandos disponíveis
+func printHelp(out io.Writer) {
+	fmt.Fprintln(out, "Comandos disponíveis:")
+	fmt.Fprintln(out, "1. send <destino> <mensagem> - Enviar mensagem unicast")
+	fmt.Fprintln(out, "   send @<grupo> <mensagem> - Enviar mensagem para um grupo multicast")
+	fmt.Fprintln(out, "2. broadcast <mensagem> - Enviar mensagem broadcast")
+	fmt.Fprintln(out, "3. status - Ver status da máquina")
+	fmt.Fprintln(out, "   stats [arquivo] - Exportar as estatísticas de tempo (fila, ACK, retransmissões, token) em CSV")
+	fmt.Fprintln(out, "4. queue - Ver fila de mensagens")
+	fmt.Fprintln(out, "5. inbox - Ler mensagens recebidas (libera o buffer de recepção)")
+	fmt.Fprintln(out, "6. join <grupo> / leave <grupo> / groups - Gerenciar grupos multicast")
+	fmt.Fprintln(out, "   mailbox - Ver mensagens guardadas (caixa postal)")
+	fmt.Fprintln(out, "   lock [segundos] / unlock - Reter o token para uma seção crítica / liberar")
+	fmt.Fprintln(out, "   put <chave> <valor> / get <chave> / del <chave> / kv - Armazenamento replicado")
+	fmt.Fprintln(out, "   elect <lcr|cr|hs> / elections - Eleição de líder e resultados")
+	fmt.Fprintln(out, "   ring - Descobrir as estações do anel e o estado de cada uma")
+	fmt.Fprintln(out, "   ping <maquina> / trace <maquina> - Testar o alcance de uma máquina / listar as estações até ela")
+	fmt.Fprintln(out, "   snapshot [arquivo] - Gravar snapshot distribuído do anel em JSON")
+	fmt.Fprintln(out, "   sync - Sincronizar os relógios do anel (esta máquina como monitor)")
+	fmt.Fprintln(out, "   bench [chave=valor ...] - Gerar tráfego e medir vazão, latência e utilização (ex: bench padrao=poisson taxa=2 duracao=30)")
+	fmt.Fprintln(out, "   watch [tipo ...] / watch off - Acompanhar os eventos da máquina (ex: watch ack nak erro_crc)")
+	fmt.Fprintln(out, "7. token - Gerar novo token (se autorizado)")
+	fmt.Fprintln(out, "8. help - Mostrar comandos")
+	fmt.Fprintln(out, "9. logs - Ver últimas linhas do arquivo de log")
+	fmt.Fprintln(out, "10. quit - Sair")
+}
+
+// execute processa uma linha de comando
+// Retorna true quando o usuário pede para encerrar a máquina
+func (c *commandLine) execute(input string) bool {
+	// Divide a entrada em partes para processamento
+	parts := strings.SplitN(input, " ", 3)
+	command := strings.ToLower(parts[0])
+
+	// Processa o comando
+	switch command {
+	case "send":
+		// Envia mensagem unicast
+		if len(parts) < 3 {
+			fmt.Fprintln(c.out, "Uso: send <destino|@grupo> <mensagem>")
+			return false
+		}
+		destination := parts[1]
+		message := parts[2]
+		err := c.machine.QueueMessage(destination, message)
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao enviar mensagem: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Mensagem adicionada à fila para %s: %s\n", destination, message)
+		}
+
+	case "broadcast":
+		// Envia mensagem broadcast
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: broadcast <mensagem>")
+			return false
+		}
+		content := strings.Join(parts[1:], " ")
+		err := c.machine.QueueMessage(message.BroadcastAddress, content)
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao enviar broadcast: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Mensagem broadcast adicionada à fila: %s\n", content)
+		}
+
+	case "status":
+		// Exibe o status da máquina
+		status := c.machine.GetStatus()
+		fmt.Fprintf(c.out, "Status da Máquina:\n")
+		fmt.Fprintf(c.out, "  Nome: %s\n", status.MachineName)
+		fmt.Fprintf(c.out, "  Possui Token: %t\n", status.HasToken)
+		fmt.Fprintf(c.out, "  Mensagens na Fila: %d\n", status.QueueSize)
+		fmt.Fprintf(c.out, "  Última Atividade: %s\n", status.LastActivity.Format("15:04:05"))
+		fmt.Fprintf(c.out, "  Tokens Processados: %d\n", status.TokensProcessed)
+		fmt.Fprintf(c.out, "  Mensagens Enviadas: %d\n", status.MessagesSent)
+		fmt.Fprintf(c.out, "  Mensagens Recebidas: %d\n", status.MessagesReceived)
+		fmt.Fprintf(c.out, "  Erros de CRC: %d | Retransmissões: %d\n", status.ErrorsDetected, status.Retransmissions)
+		fmt.Fprintf(c.out, "  Relógio Lógico: %s\n", c.machine.GetClock())
+		wallClock := c.machine.GetWallClock()
+		fmt.Fprintf(c.out, "  Horário: %s (correção %+v, ajustes recebidos: %d)\n",
+			c.machine.Now().Format("15:04:05.000"), wallClock.Offset().Round(time.Microsecond), wallClock.Adjustments())
+		fmt.Fprintf(c.out, "  Grupos: %s\n", strings.Join(c.machine.GetGroups(), ", "))
+		fmt.Fprintf(c.out, "  Buffer de Recepção: %d/%d\n", status.InboxSize, c.cfg.ReceiveBufferSize)
+		fmt.Fprintf(c.out, "  Quadros Recusados (BUSY): %d\n", status.BusyReplies)
+		fmt.Fprintf(c.out, "  Respostas BUSY Recebidas: %d\n", status.BusyReceived)
+		fmt.Fprintf(c.out, "  Mensagens Adiadas (caixa postal): %d\n", status.MessagesDeferred)
+		if c.cfg.Mailbox {
+			fmt.Fprintf(c.out, "  Caixa Postal: %d mensagens guardadas\n", status.MailboxSize)
+		}
+		order := c.machine.GetOrderStatus()
+		fmt.Fprintf(c.out, "  Ordem Total: última sequência=%d, próxima entrega=%d, entregues=%d, retidas=%d, a enviar=%d\n",
+			order.LastSeq, order.NextDeliver, order.Delivered, order.Pending, order.QueuedToSend)
+		lock := c.machine.GetLockStats()
+		fmt.Fprintf(c.out, "  Seção Crítica: em posse=%t, aguardando=%t, aquisições=%d, expiradas=%d\n",
+			lock.Held, lock.Waiting, lock.Acquisitions, lock.Expired)
+		fmt.Fprintf(c.out, "  Espera pelo Token: última=%v, média=%v, máxima=%v\n",
+			lock.LastWait.Round(time.Millisecond), lock.AverageWait().Round(time.Millisecond), lock.MaxWait.Round(time.Millisecond))
+		if report := c.machine.GetLastBroadcastReport(); report != nil {
+			fmt.Fprintf(c.out, "  Último Broadcast/Multicast: \"%s\" (%s)\n", report.Content, report)
+		}
+		timing := c.machine.GetTimingStats()
+		fmt.Fprintf(c.out, "  Espera na Fila: %s\n", formatDurations(timing.QueueDelay))
+		fmt.Fprintf(c.out, "  Tempo até o ACK: %s\n", formatDurations(timing.TimeToACK))
+		fmt.Fprintf(c.out, "  Retransmissões por Mensagem Entregue: %s\n", formatCounts(timing.Retries))
+		fmt.Fprintf(c.out, "  Volta do Token: %s\n", formatDurations(timing.TokenRotation))
+
+	case "stats":
+		// Exporta as estatísticas de tempo em CSV
+		file := fmt.Sprintf("estatisticas_%s.csv", c.cfg.MachineName)
+		if len(parts) > 1 {
+			file = parts[1]
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao criar arquivo: %v\n", err)
+			return false
+		}
+		err = c.machine.GetTimingStats().WriteCSV(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Estatísticas de tempo gravadas em %s\n", file)
+
+	case "queue":
+		// Exibe a fila de mensagens
+		queue := c.machine.GetMessageQueue()
+		if len(queue) == 0 {
+			fmt.Fprintln(c.out, "Fila de mensagens vazia")
+		} else {
+			fmt.Fprintf(c.out, "Fila de mensagens (%d/%d):\n", len(queue), c.machine.QueueCapacity())
+			for i, msg := range queue {
+				fmt.Fprintf(c.out, "  %d. Para: %s | Mensagem: %s\n", i+1, msg.Destination, msg.Content)
+			}
+		}
+
+	case "inbox":
+		// Lê (e remove) as mensagens do buffer de recepção
+		inbox := c.machine.ReadInbox()
+		if len(inbox) == 0 {
+			fmt.Fprintln(c.out, "Nenhuma mensagem recebida")
+		} else {
+			fmt.Fprintf(c.out, "Mensagens recebidas (%d):\n", len(inbox))
+			for i, msg := range inbox {
+				fmt.Fprintf(c.out, "  %d. [%s] De: %s | Mensagem: %s\n", i+1, msg.Timestamp.Format("15:04:05"), msg.Origin, msg.Content)
+			}
+		}
+
+	case "join":
+		// Entra em um grupo multicast
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: join <grupo>")
+			return false
+		}
+		if err := c.machine.JoinGroup(parts[1]); err != nil {
+			fmt.Fprintf(c.out, "Erro ao entrar no grupo: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Participando do grupo %s\n", message.GroupAddress(parts[1]))
+		}
+
+	case "leave":
+		// Sai de um grupo multicast
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: leave <grupo>")
+			return false
+		}
+		if err := c.machine.LeaveGroup(parts[1]); err != nil {
+			fmt.Fprintf(c.out, "Erro ao sair do grupo: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Saiu do grupo %s\n", message.GroupAddress(parts[1]))
+		}
+
+	case "groups":
+		// Lista os grupos multicast da máquina
+		groups := c.machine.GetGroups()
+		if len(groups) == 0 {
+			fmt.Fprintln(c.out, "A máquina não participa de nenhum grupo")
+		} else {
+			fmt.Fprintf(c.out, "Grupos: %s\n", strings.Join(groups, ", "))
+		}
+
+	case "mailbox":
+		// Lista as mensagens guardadas pela caixa postal
+		entries, err := c.machine.GetMailbox()
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro: %v\n", err)
+		} else if len(entries) == 0 {
+			fmt.Fprintln(c.out, "Caixa postal vazia")
+		} else {
+			fmt.Fprintf(c.out, "Caixa postal (%d):\n", len(entries))
+			for i, e := range entries {
+				fmt.Fprintf(c.out, "  %d. [%s] De: %s | Para: %s | Tentativas: %d | Mensagem: %s\n",
+					i+1, e.Stored.Format("15:04:05"), e.Origin, e.Destination, e.Attempts, e.Message)
+			}
+		}
+
+	case "put":
+		// Grava uma chave no armazenamento replicado
+		if len(parts) < 3 {
+			fmt.Fprintln(c.out, "Uso: put <chave> <valor>")
+			return false
+		}
+		if err := c.store.Put(parts[1], parts[2]); err != nil {
+			fmt.Fprintf(c.out, "Erro ao gravar chave: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Gravação de %s adicionada à fila (aplicada quando entregue na ordem total)\n", parts[1])
+		}
+
+	case "get":
+		// Lê uma chave da cópia local do armazenamento replicado
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: get <chave>")
+			return false
+		}
+		if value, ok := c.store.Get(parts[1]); ok {
+			fmt.Fprintf(c.out, "%s = %s\n", parts[1], value)
+		} else {
+			fmt.Fprintf(c.out, "Chave %s não encontrada\n", parts[1])
+		}
+
+	case "del":
+		// Remove uma chave do armazenamento replicado
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: del <chave>")
+			return false
+		}
+		if err := c.store.Delete(parts[1]); err != nil {
+			fmt.Fprintf(c.out, "Erro ao remover chave: %v\n", err)
+		} else {
+			fmt.Fprintf(c.out, "Remoção de %s adicionada à fila (aplicada quando entregue na ordem total)\n", parts[1])
+		}
+
+	case "kv":
+		// Lista o conteúdo da cópia local do armazenamento replicado
+		keys := c.store.Keys()
+		fmt.Fprintf(c.out, "Armazenamento replicado (%d chaves, última operação #%d):\n", len(keys), c.store.AppliedSeq())
+		for _, key := range keys {
+			value, _ := c.store.Get(key)
+			fmt.Fprintf(c.out, "  %s = %s\n", key, value)
+		}
+
+	case "elect":
+		// Inicia uma eleição de líder e aguarda o resultado
+		if len(parts) < 2 {
+			fmt.Fprintf(c.out, "Uso: elect <%s>\n", strings.Join(election.Algorithms(), "|"))
+			return false
+		}
+		id, err := c.elections.Start(strings.ToLower(parts[1]))
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao iniciar eleição: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Eleição %s iniciada, aguardando resultado...\n", id)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		result, err := c.elections.Wait(ctx, id)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Eleição %s não concluída: %v\n", id, err)
+		} else {
+			fmt.Fprintf(c.out, "Líder: %s | Estações: %d | Mensagens do algoritmo: %d | Total com anúncio: %d | Duração: %v\n",
+				result.Leader, result.RingSize, result.ElectionMessages, result.TotalMessages, result.Duration.Round(time.Millisecond))
+		}
+
+	case "elections":
+		// Lista as eleições concluídas
+		results := c.elections.Results()
+		if len(results) == 0 {
+			fmt.Fprintln(c.out, "Nenhuma eleição concluída")
+		}
+		for _, result := range results {
+			fmt.Fprintf(c.out, "  %s\n", result)
+		}
+
+	case "ring":
+		// Envia um censo pelo anel e exibe as estações encontradas
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ring, err := c.census.Discover(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Censo não retornou: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Anel com %d estações (volta em %v):\n", len(ring.Stations), ring.RTT.Round(time.Microsecond))
+		for _, station := range ring.Stations {
+			token := ""
+			if station.HasToken {
+				token = " [token]"
+			}
+			fmt.Fprintf(c.out, "  %s%s - fila: %d, buffer: %d, enviadas: %d, recebidas: %d\n",
+				station.Name, token, station.Queue, station.Inbox, station.Sent, station.Received)
+		}
+
+	case "ping":
+		// Envia um eco até a máquina e exibe o tempo de volta e o número de saltos
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: ping <maquina>")
+			return false
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		result, err := c.machine.Ping(ctx, parts[1])
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Ping para %s falhou: %v\n", parts[1], err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Resposta de %s: %d salto(s), volta em %v (anel com %d estações)\n",
+			result.Destination, result.Hops, result.RTT.Round(time.Microsecond), result.RingSize)
+
+	case "trace":
+		// Lista as estações por onde o pacote passou, com o horário de chegada a cada uma
+		if len(parts) < 2 {
+			fmt.Fprintln(c.out, "Uso: trace <maquina>")
+			return false
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		result, err := c.machine.Trace(ctx, parts[1])
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Trace para %s falhou: %v\n", parts[1], err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Rota até %s (volta em %v):\n", result.Destination, result.RTT.Round(time.Microsecond))
+		for i, hop := range result.Path {
+			line := fmt.Sprintf("  %2d  %-10s %s", i, hop.Station, hop.Time.Format("15:04:05.000000"))
+			if i > 0 {
+				elapsed := hop.Elapsed.Round(time.Microsecond)
+				if elapsed >= 0 {
+					line += "  +" + elapsed.String()
+				} else {
+					line += "  " + elapsed.String()
+				}
+			}
+			if hop.Destination {
+				line += "  [destino]"
+			}
+			fmt.Fprintln(c.out, line)
+		}
+		if !result.Reached {
+			fmt.Fprintf(c.out, "Máquina %s não encontrada no anel\n", result.Destination)
+		}
+
+	case "bench":
+		// Gera tráfego pela fila de mensagens e exibe o relatório ao final
+		c.bench(context.Background(), strings.Fields(input)[1:])
+
+	case "watch":
+		// Acompanha os eventos da máquina, opcionalmente apenas os tipos informados
+		c.watch(strings.Fields(input)[1:])
+
+	case "snapshot":
+		// Grava um snapshot consistente do anel (Chandy–Lamport)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		snap, err := c.machine.TakeSnapshot(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Snapshot não concluído: %v\n", err)
+			return false
+		}
+		file := fmt.Sprintf("snapshot_%s.json", snap.ID)
+		if len(parts) > 1 {
+			file = parts[1]
+		}
+		if err := snap.WriteFile(file); err != nil {
+			fmt.Fprintf(c.out, "Erro: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Snapshot %s gravado em %s (%d estações, %d quadros em trânsito)\n",
+			snap.ID, file, len(snap.Stations), len(snap.InFlight))
+
+	case "sync":
+		// Executa uma rodada do algoritmo de Berkeley com esta máquina como monitor
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		result, err := c.clocks.Sync(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Sincronização não concluída: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "Sincronização concluída em %v (RTT) - média das diferenças: %+v\n",
+			result.RTT.Round(time.Microsecond), result.Average.Round(time.Microsecond))
+		for _, station := range result.Stations {
+			fmt.Fprintf(c.out, "  %s: diferença %+v, correção %+v\n", station,
+				result.Offsets[station].Round(time.Microsecond), result.Corrections[station].Round(time.Microsecond))
+		}
+		if len(result.Excluded) > 0 {
+			fmt.Fprintf(c.out, "  Ignoradas na média: %s\n", strings.Join(result.Excluded, ", "))
+		}
+
+	case "lock":
+		// Obtém o token para uma seção crítica (bloqueia até o token chegar)
+		timeout := 30 * time.Second
+		if len(parts) > 1 {
+			seconds, err := strconv.Atoi(parts[1])
+			if err != nil || seconds <= 0 {
+				fmt.Fprintln(c.out, "Uso: lock [tempo_limite_segundos]")
+				return false
+			}
+			timeout = time.Duration(seconds) * time.Second
+		}
+		fmt.Fprintln(c.out, "Aguardando token...")
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := c.machine.Acquire(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao obter seção crítica: %v\n", err)
+		} else {
+			stats := c.machine.GetLockStats()
+			fmt.Fprintf(c.out, "Seção crítica obtida após %v (liberação automática em %v). Use 'unlock' para liberar.\n",
+				stats.LastWait.Round(time.Millisecond), stats.MaxHold)
+		}
+
+	case "unlock":
+		// Libera a seção crítica e devolve o token ao anel
+		if err := c.machine.Release(); err != nil {
+			fmt.Fprintf(c.out, "Erro ao liberar seção crítica: %v\n", err)
+		} else {
+			fmt.Fprintln(c.out, "Seção crítica liberada")
+		}
+
+	case "token":
+		// Gera um novo token
+		err := c.machine.GenerateToken()
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao gerar token: %v\n", err)
+		} else {
+			fmt.Fprintln(c.out, "Novo token gerado e enviado")
+		}
+
+	case "help":
+		// Exibe ajuda
+		fmt.Fprintln(c.out)
+		printHelp(c.out)
+
+	case "logs":
+		// Exibe as últimas linhas do arquivo de log
+		if c.cfg.LogFile == "" {
+			fmt.Fprintln(c.out, "Logs não estão sendo gravados em arquivo.")
+			return false
+		}
+
+		file, err := os.Open(c.cfg.LogFile)
+		if err != nil {
+			fmt.Fprintf(c.out, "Erro ao abrir arquivo de log: %v\n", err)
+			return false
+		}
+		defer file.Close()
+
+		// Lê as últimas 20 linhas do arquivo
+		scanner := bufio.NewScanner(file)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+			if len(lines) > 20 {
+				lines = lines[1:]
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(c.out, "Erro ao ler arquivo de log: %v\n", err)
+			return false
+		}
+
+		// Exibe as linhas
+		fmt.Fprintln(c.out, "\n=== Últimas linhas do log ===")
+		if len(lines) == 0 {
+			fmt.Fprintln(c.out, "Nenhum log encontrado.")
+		} else {
+			for _, line := range lines {
+				fmt.Fprintln(c.out, line)
+			}
+		}
+		fmt.Fprintln(c.out, "=============================")
+
+	case "quit", "exit":
+		// Encerra a máquina
+		fmt.Fprintln(c.out, "Encerrando máquina...")
+		return true
+
+	default:
+		fmt.Fprintf(c.out, "Comando desconhecido: %s. Digite 'help' para ver os comandos disponíveis.\n", command)
+	}
+
+	return false
+}
+
+// watch passa a exibir os eventos da máquina dos tipos informados (todos, se nenhum)
+// "watch off" encerra o acompanhamento
+func (c *commandLine) watch(types []string) {
+	if c.stopWatch != nil {
+		c.stopWatch()
+		c.stopWatch = nil
+	}
+	if len(types) == 1 && types[0] == "off" {
+		fmt.Fprintln(c.out, "Acompanhamento de eventos encerrado")
+		return
+	}
+
+	filter := make(map[network.EventType]bool)
+	for _, t := range types {
+		filter[network.EventType(strings.ToLower(t))] = true
+	}
+
+	events, cancel := c.machine.Subscribe(100)
+	c.stopWatch = cancel
+	out := c.out
+	go func() {
+		for event := range events {
+			if len(filter) == 0 || filter[event.Type] {
+				fmt.Fprintf(out, "[evento %s] %s\n", event.Time.Format("15:04:05.000"), eventSummary(event))
+			}
+		}
+	}()
+
+	if len(filter) == 0 {
+		fmt.Fprintln(c.out, "Acompanhando todos os eventos (watch off para encerrar)")
+	} else {
+		fmt.Fprintf(c.out, "Acompanhando eventos: %s (watch off para encerrar)\n", strings.Join(types, ", "))
+	}
+}
+
+// bench executa um benchmark com as opções chave=valor e exibe o relatório
+// Sem destinos informados, usa as demais estações encontradas por um censo
+// Retorna false se o benchmark não pôde ser executado
+func (c *commandLine) bench(ctx context.Context, args []string) bool {
+	opts, err := bench.ParseOptions(args)
+	if err != nil {
+		fmt.Fprintf(c.out, "Erro: %v\n", err)
+		fmt.Fprintln(c.out, "Uso: bench [padrao=constante|poisson|rajada] [taxa=<msg/s>] [duracao=<s>] [rajada=<n>]")
+		fmt.Fprintln(c.out, "           [destinos=A,B] [hotspot=<maquina>[:<fração>]] [tamanho=<bytes>] [drenagem=<s>] [semente=<n>]")
+		return false
+	}
+
+	if len(opts.Destinations) == 0 {
+		censusCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		ring, err := c.census.Discover(censusCtx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(c.out, "Censo não retornou, informe os destinos (destinos=A,B): %v\n", err)
+			return false
+		}
+		for _, station := range ring.Stations {
+			if station.Name != c.cfg.MachineName {
+				opts.Destinations = append(opts.Destinations, station.Name)
+			}
+		}
+	}
+	if opts.Hotspot != "" && !slices.Contains(opts.Destinations, opts.Hotspot) {
+		opts.Destinations = append(opts.Destinations, opts.Hotspot)
+	}
+
+	fmt.Fprintf(c.out, "Benchmark iniciado: %s (drenagem até %v)\n", opts, opts.Drain)
+	result, err := bench.Run(ctx, c.machine, opts)
+	if result == nil {
+		fmt.Fprintf(c.out, "Erro: %v\n", err)
+		return false
+	}
+	if err != nil {
+		fmt.Fprintf(c.out, "Benchmark interrompido: %v\n", err)
+	}
+	result.WriteReport(c.out)
+	return err == nil
+}
+
+// formatDurations resume uma série de tempos em segundos: mín/média/p50/p95/máx
+func formatDurations(s metrics.SummarySnapshot) string {
+	if s.Samples == 0 {
+		return "sem amostras"
+	}
+	d := func(seconds float64) time.Duration {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond)
+	}
+	return fmt.Sprintf("mín %v, média %v, p50 %v, p95 %v, máx %v (%d amostra(s))",
+		d(s.Min), d(s.Avg), d(s.P50), d(s.P95), d(s.Max), s.Samples)
+}
+
+// formatCounts resume uma série de contagens: mín/média/p50/p95/máx
+func formatCounts(s metrics.SummarySnapshot) string {
+	if s.Samples == 0 {
+		return "sem amostras"
+	}
+	return fmt.Sprintf("mín %g, média %.2f, p50 %g, p95 %g, máx %g (%d amostra(s))",
+		s.Min, s.Avg, s.P50, s.P95, s.Max, s.Samples)
+}
+
+// eventSummary descreve um evento da máquina em uma linha
+func eventSummary(event network.Event) string {
+	text := string(event.Type)
+	switch event.Type {
+	case network.EventFrameSent, network.EventFrameReceived:
+		return text + ": " + message.Describe(event.Frame)
+	}
+	if event.Origin != "" || event.Destination != "" {
+		text += fmt.Sprintf(" %s→%s", event.Origin, event.Destination)
+	}
+	if event.Message != "" {
+		text += fmt.Sprintf(" %q", event.Message)
+	}
+	if event.Detail != "" {
+		text += " (" + event.Detail + ")"
+	}
+	return text
+}
@@ -2,27 +2,47 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 
+	"ring-network/pkg/api"
+	"ring-network/pkg/capture"
 	"ring-network/pkg/config"
+	"ring-network/pkg/dashboard"
+	"ring-network/pkg/discovery"
+	"ring-network/pkg/election"
+	"ring-network/pkg/kvstore"
+	"ring-network/pkg/logging"
 	"ring-network/pkg/network"
+	"ring-network/pkg/timesync"
 )
 
 // main é o ponto de entrada da aplicação
 // Inicializa a máquina da rede em anel e a interface de comandos
 func main() {
+	tuiMode := flag.Bool("tui", false, "Interface de tela cheia com painéis atualizados em tempo real")
+	benchSpec := flag.String("bench", "", "Executa um benchmark sem interface (opções do comando bench, ex: \"taxa=2 duracao=30\") e encerra")
+	flag.Parse()
+
 	// Verifica se foi fornecido o arquivo de configuração
-	if len(os.Args) < 2 {
-		fmt.Println("Uso: go run main.go <arquivo_de_configuracao>")
-		fmt.Println("Exemplo: go run main.go config.txt")
+	if flag.NArg() < 1 {
+		fmt.Println("Uso: go run ./cmd/machine [--tui | --bench \"<opções>\"] <arquivo_de_configuracao>")
+		fmt.Println("Exemplo: go run ./cmd/machine config.txt")
+		os.Exit(1)
+	}
+	if *tuiMode && *benchSpec != "" {
+		fmt.Println("As opções --tui e --bench não podem ser usadas juntas")
 		os.Exit(1)
 	}
 
-	configFile := os.Args[1]
+	configFile := flag.Arg(0)
 
 	// Carrega a configuração do arquivo
 	cfg, err := config.LoadConfig(configFile)
@@ -31,13 +51,29 @@ func main() {
 	}
 
 	// Configura o sistema de log
-	if err := cfg.SetupLogger(); err != nil {
+	logOptions := cfg.LogOptions()
+	if *tuiMode {
+		// No modo de tela cheia, linhas de log no terminal desfariam os painéis
+		logOptions.Mirror = nil
+	}
+	logger, logCloser, err := logging.New(logOptions)
+	if err != nil {
 		fmt.Printf("Aviso: Não foi possível configurar o arquivo de log: %v\n", err)
-		fmt.Println("Os logs serão exibidos apenas no terminal.")
+		cfg.LogFile = ""
+		logOptions.File = ""
+		if !*tuiMode {
+			fmt.Println("Os logs serão exibidos apenas no terminal.")
+			logOptions.Mirror = os.Stderr
+		}
+		logger, logCloser, _ = logging.New(logOptions)
 	} else {
 		fmt.Printf("Logs sendo gravados em: %s\n", cfg.LogFile)
-		fmt.Println("O terminal agora está limpo para comandos.")
+		if !cfg.LogMirror {
+			fmt.Println("O terminal agora está limpo para comandos.")
+		}
 	}
+	defer logCloser.Close()
+	logger.Info("=== Iniciando logs ===", "maquina", cfg.MachineName)
 
 	// Exibe informações de inicialização
 	fmt.Printf("=== Iniciando Máquina da Rede em Anel ===\n")
@@ -47,179 +83,132 @@ func main() {
 	fmt.Printf("Gera token inicial: %t\n", cfg.GeneratesToken)
 	fmt.Println("=====================================")
 
+	options := []network.Option{network.WithConfig(cfg), network.WithLogger(logger)}
+
+	// Captura dos quadros (pcapng ou JSONL), se configurada
+	if cfg.CaptureFile != "" {
+		frames, err := capture.Open(cfg.CaptureFile, cfg.MachineName)
+		if err != nil {
+			log.Fatalf("Erro ao iniciar captura: %v", err)
+		}
+		defer frames.Close()
+		options = append(options, network.WithCapture(frames))
+		fmt.Printf("Quadros capturados em: %s\n", cfg.CaptureFile)
+	}
+
 	// Cria a máquina com a configuração carregada
-	machine, err := network.NewMachine(cfg)
+	machine, err := network.New(options...)
 	if err != nil {
 		log.Fatalf("Erro ao criar máquina: %v", err)
 	}
 
-	// Inicia a máquina em uma goroutine separada
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// Armazenamento chave-valor replicado pela difusão com ordem total
+	store := kvstore.New(machine)
+
+	// Serviço de eleição de líder sobre os pacotes de controle da máquina
+	elections, err := election.NewService(machine)
+	if err != nil {
+		log.Fatalf("Erro ao criar serviço de eleição: %v", err)
+	}
+
+	// Sincronização de relógios (algoritmo de Berkeley), periódica se configurada
+	clocks, err := timesync.NewService(machine, time.Duration(cfg.SyncInterval)*time.Second)
+	if err != nil {
+		log.Fatalf("Erro ao criar serviço de sincronização de relógios: %v", err)
+	}
+
+	// Descoberta das estações do anel (todas as estações respondem aos censos)
+	census, err := discovery.NewService(machine)
+	if err != nil {
+		log.Fatalf("Erro ao criar serviço de descoberta: %v", err)
+	}
+
+	// Servidor HTTP de administração e painel, se configurado
+	if cfg.AdminAddr != "" {
+		admin := api.NewServer(machine)
+		dashboard.New(machine, census, dashboard.DefaultInterval).Register(admin)
+		if err := admin.Start(cfg.AdminAddr); err != nil {
+			log.Fatalf("Erro ao iniciar API de administração: %v", err)
+		}
+		fmt.Printf("API HTTP de administração e painel em: %s\n", cfg.AdminAddr)
+	}
+
+	// A máquina executa até o comando quit, Ctrl+C ou um erro fatal do transporte
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	result := make(chan error, 1)
 	go func() {
-		defer wg.Done()
-		machine.Start()
+		result <- machine.Run(ctx)
 	}()
 
-	// Inicia a interface de comandos em outra goroutine
-	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		fmt.Println("\n=== Interface de Comandos ===")
-		fmt.Println("Comandos disponíveis:")
-		fmt.Println("1. send <destino> <mensagem> - Enviar mensagem unicast")
-		fmt.Println("2. broadcast <mensagem> - Enviar mensagem broadcast")
-		fmt.Println("3. status - Ver status da máquina")
-		fmt.Println("4. queue - Ver fila de mensagens")
-		fmt.Println("5. token - Gerar novo token (se autorizado)")
-		fmt.Println("6. help - Mostrar comandos")
-		fmt.Println("7. logs - Ver últimas linhas do arquivo de log")
-		fmt.Println("8. quit - Sair")
-		fmt.Println("============================")
-
-		// Loop principal da interface de comandos
-		for {
-			fmt.Print("\n> ")
-			if !scanner.Scan() {
-				break
-			}
+	cli := &commandLine{
+		cfg:       cfg,
+		machine:   machine,
+		store:     store,
+		elections: elections,
+		clocks:    clocks,
+		census:    census,
+		out:       os.Stdout,
+	}
 
-			input := strings.TrimSpace(scanner.Text())
-			if input == "" {
-				continue
+	benchDone := make(chan bool, 1)
+	switch {
+	case *benchSpec != "":
+		// Benchmark sem interface: executa, exibe o relatório e encerra a máquina
+		go func() {
+			benchDone <- cli.bench(ctx, strings.Fields(*benchSpec))
+			cancel()
+		}()
+	case *tuiMode:
+		// No modo de tela cheia, a interface ocupa o terminal até o comando quit
+		go func() {
+			runTUI(cli)
+			cancel()
+		}()
+	default:
+		go func() {
+			if runPrompt(cli) {
+				cancel()
 			}
+		}()
+	}
 
-			// Divide a entrada em partes para processamento
-			parts := strings.SplitN(input, " ", 3)
-			command := strings.ToLower(parts[0])
-
-			// Processa o comando
-			switch command {
-			case "send":
-				// Envia mensagem unicast
-				if len(parts) < 3 {
-					fmt.Println("Uso: send <destino> <mensagem>")
-					continue
-				}
-				destination := parts[1]
-				message := parts[2]
-				err := machine.QueueMessage(destination, message)
-				if err != nil {
-					fmt.Printf("Erro ao enviar mensagem: %v\n", err)
-				} else {
-					fmt.Printf("Mensagem adicionada à fila para %s: %s\n", destination, message)
-				}
-
-			case "broadcast":
-				// Envia mensagem broadcast
-				if len(parts) < 2 {
-					fmt.Println("Uso: broadcast <mensagem>")
-					continue
-				}
-				message := strings.Join(parts[1:], " ")
-				err := machine.QueueMessage("TODOS", message)
-				if err != nil {
-					fmt.Printf("Erro ao enviar broadcast: %v\n", err)
-				} else {
-					fmt.Printf("Mensagem broadcast adicionada à fila: %s\n", message)
-				}
-
-			case "status":
-				// Exibe o status da máquina
-				status := machine.GetStatus()
-				fmt.Printf("Status da Máquina:\n")
-				fmt.Printf("  Nome: %s\n", status.MachineName)
-				fmt.Printf("  Possui Token: %t\n", status.HasToken)
-				fmt.Printf("  Mensagens na Fila: %d\n", status.QueueSize)
-				fmt.Printf("  Última Atividade: %s\n", status.LastActivity.Format("15:04:05"))
-				fmt.Printf("  Tokens Processados: %d\n", status.TokensProcessed)
-				fmt.Printf("  Mensagens Enviadas: %d\n", status.MessagesSent)
-				fmt.Printf("  Mensagens Recebidas: %d\n", status.MessagesReceived)
-
-			case "queue":
-				// Exibe a fila de mensagens
-				queue := machine.GetMessageQueue()
-				if len(queue) == 0 {
-					fmt.Println("Fila de mensagens vazia")
-				} else {
-					fmt.Printf("Fila de mensagens (%d/%d):\n", len(queue), 10)
-					for i, msg := range queue {
-						fmt.Printf("  %d. Para: %s | Mensagem: %s\n", i+1, msg.Destination, msg.Content)
-					}
-				}
-
-			case "token":
-				// Gera um novo token
-				err := machine.GenerateToken()
-				if err != nil {
-					fmt.Printf("Erro ao gerar token: %v\n", err)
-				} else {
-					fmt.Println("Novo token gerado e enviado")
-				}
-
-			case "help":
-				// Exibe ajuda
-				fmt.Println("\nComandos disponíveis:")
-				fmt.Println("1. send <destino> <mensagem> - Enviar mensagem unicast")
-				fmt.Println("2. broadcast <mensagem> - Enviar mensagem broadcast")
-				fmt.Println("3. status - Ver status da máquina")
-				fmt.Println("4. queue - Ver fila de mensagens")
-				fmt.Println("5. token - Gerar novo token (se autorizado)")
-				fmt.Println("6. help - Mostrar comandos")
-				fmt.Println("7. logs - Ver últimas linhas do arquivo de log")
-				fmt.Println("8. quit - Sair")
-
-			case "logs":
-				// Exibe as últimas linhas do arquivo de log
-				if cfg.LogFile == "" {
-					fmt.Println("Logs não estão sendo gravados em arquivo.")
-					continue
-				}
-
-				file, err := os.Open(cfg.LogFile)
-				if err != nil {
-					fmt.Printf("Erro ao abrir arquivo de log: %v\n", err)
-					continue
-				}
-				defer file.Close()
-
-				// Lê as últimas 20 linhas do arquivo
-				scanner := bufio.NewScanner(file)
-				var lines []string
-				for scanner.Scan() {
-					lines = append(lines, scanner.Text())
-					if len(lines) > 20 {
-						lines = lines[1:]
-					}
-				}
-
-				if err := scanner.Err(); err != nil {
-					fmt.Printf("Erro ao ler arquivo de log: %v\n", err)
-					continue
-				}
-
-				// Exibe as linhas
-				fmt.Println("\n=== Últimas linhas do log ===")
-				if len(lines) == 0 {
-					fmt.Println("Nenhum log encontrado.")
-				} else {
-					for _, line := range lines {
-						fmt.Println(line)
-					}
-				}
-				fmt.Println("=============================")
-
-			case "quit", "exit":
-				// Encerra a máquina
-				fmt.Println("Encerrando máquina...")
-				machine.Stop()
-				os.Exit(0)
-
-			default:
-				fmt.Printf("Comando desconhecido: %s. Digite 'help' para ver os comandos disponíveis.\n", command)
-			}
+	err = <-result
+	if *tuiMode {
+		fmt.Print(ansiMainScreen)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Máquina encerrada com erro: %v\n", err)
+		os.Exit(1)
+	}
+	if *benchSpec != "" && !<-benchDone {
+		os.Exit(1)
+	}
+}
+
+// runPrompt executa a interface de comandos no terminal
+// Retorna true se o usuário pediu para encerrar; false se a entrada terminou
+func runPrompt(cli *commandLine) bool {
+	scanner := bufio.NewScanner(os.Stdin)
+	fmt.Println("\n=== Interface de Comandos ===")
+	printHelp(os.Stdout)
+	fmt.Println("============================")
+
+	// Loop principal da interface de comandos
+	for {
+		fmt.Print("\n> ")
+		if !scanner.Scan() {
+			return false
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			continue
 		}
-	}()
 
-	// Aguarda a finalização da goroutine principal
-	wg.Wait()
+		if cli.execute(input) {
+			return true
+		}
+	}
 }
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+)
+
+// Sequências ANSI usadas pelo modo de tela cheia
+const (
+	ansiAltScreen    = "\x1b[?1049h" // Usa a tela alternativa (preserva o terminal ao sair)
+	ansiMainScreen   = "\x1b[?1049l" // Volta à tela principal
+	ansiClear        = "\x1b[2J"
+	ansiClearLine    = "\x1b[K"
+	ansiSaveCursor   = "\x1b7"
+	ansiRestore      = "\x1b8"
+	ansiBold         = "\x1b[1m"
+	ansiReverse      = "\x1b[7m"
+	ansiReset        = "\x1b[0m"
+	tuiRefresh       = 500 * time.Millisecond
+	tuiTrafficLines  = 200 // Quadros guardados no painel de tráfego
+	tuiOutputLines   = 200 // Linhas guardadas no painel de saída
+	tuiDefaultWidth  = 120
+	tuiDefaultHeight = 36
+	tuiMinWidth      = 60
+	tuiMinHeight     = 16
+)
+
+// lineBuffer guarda as últimas linhas escritas, para os painéis de tráfego e de saída
+// Implementa io.Writer para receber a saída dos comandos
+type lineBuffer struct {
+	lines   []string
+	partial string
+	max     int
+	mutex   sync.Mutex
+}
+
+// Write separa o texto em linhas e guarda as mais recentes
+func (lb *lineBuffer) Write(p []byte) (int, error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	text := lb.partial + string(p)
+	parts := strings.Split(text, "\n")
+	lb.partial = parts[len(parts)-1]
+	lb.lines = append(lb.lines, parts[:len(parts)-1]...)
+	if len(lb.lines) > lb.max {
+		lb.lines = lb.lines[len(lb.lines)-lb.max:]
+	}
+	return len(p), nil
+}
+
+// Last retorna as últimas n linhas
+func (lb *lineBuffer) Last(n int) []string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lines := lb.lines
+	if lb.partial != "" {
+		lines = append(lines[:len(lines):len(lines)], lb.partial)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return append([]string(nil), lines...)
+}
+
+// tui é o modo de tela cheia: painéis com o estado da máquina, atualizados no lugar,
+// e uma linha de comando na parte inferior que aceita os mesmos comandos do modo normal
+type tui struct {
+	cli     *commandLine
+	traffic *lineBuffer
+	output  *lineBuffer
+	width   int
+	height  int
+	closed  bool       // Indica que a interface terminou e não deve mais desenhar
+	mutex   sync.Mutex // Serializa as escritas no terminal
+}
+
+// runTUI executa a interface de tela cheia até o comando quit ou o fim da entrada
+func runTUI(cli *commandLine) {
+	t := &tui{
+		cli:     cli,
+		traffic: &lineBuffer{max: tuiTrafficLines},
+		output:  &lineBuffer{max: tuiOutputLines},
+	}
+	cli.out = t.output
+
+	// Todo o tráfego da máquina é decodificado no painel de tráfego, junto com as falhas
+	cli.machine.OnEvent(func(event network.Event) {
+		var line string
+		switch event.Type {
+		case network.EventFrameSent:
+			line = "→ " + message.Describe(event.Frame)
+		case network.EventFrameReceived:
+			line = "← " + message.Describe(event.Frame)
+		case network.EventCRCError, network.EventTokenLost:
+			line = "! " + eventSummary(event)
+		default:
+			return
+		}
+		fmt.Fprintf(t.traffic, "%s %s\n", event.Time.Format("15:04:05.000"), line)
+	})
+
+	fmt.Print(ansiAltScreen)
+	fmt.Fprintln(t.output, "Digite 'help' para ver os comandos disponíveis.")
+	t.redraw(true)
+
+	go func() {
+		ticker := time.NewTicker(tuiRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			t.redraw(false)
+		}
+	}()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		input := strings.TrimSpace(scanner.Text())
+		if input != "" {
+			fmt.Fprintf(t.output, "> %s\n", input)
+			if cli.execute(input) {
+				break
+			}
+		}
+		t.redraw(true)
+	}
+
+	// Impede novos desenhos; a tela principal é restaurada quando a máquina termina
+	t.mutex.Lock()
+	t.closed = true
+	t.mutex.Unlock()
+}
+
+// redraw desenha os painéis
+// full limpa a tela e reposiciona a linha de comando (após cada comando); caso contrário,
+// apenas os painéis são redesenhados, preservando o que o usuário está digitando
+func (t *tui) redraw(full bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	var b strings.Builder
+	if full {
+		t.width, t.height = terminalSize()
+		t.width = max(t.width, tuiMinWidth)
+		t.height = max(t.height, tuiMinHeight)
+		b.WriteString(ansiClear)
+	} else {
+		b.WriteString(ansiSaveCursor)
+	}
+
+	rows := t.render()
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s%s", i+1, row, ansiClearLine)
+	}
+
+	if full {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s> ", t.height, ansiClearLine)
+	} else {
+		b.WriteString(ansiRestore)
+	}
+	fmt.Print(b.String())
+}
+
+// render monta as linhas da tela, exceto a linha de comando
+func (t *tui) render() []string {
+	machine := t.cli.machine
+	status := machine.GetStatus()
+	body := t.height - 2 // Título e linha de comando
+
+	token := "não"
+	if status.HasToken {
+		token = ansiReverse + " POSSUI O TOKEN " + ansiReset
+	}
+	title := fmt.Sprintf("%s Rede em Anel - %s %s  token: %s  |  %s  |  %s", ansiBold, status.MachineName, ansiReset,
+		token, machine.GetClock(), machine.Now().Format("15:04:05"))
+
+	// Coluna esquerda: token, contadores, fila e buffer de recepção
+	lock := machine.GetLockStats()
+	left := []string{pane("Token")}
+	left = append(left,
+		fmt.Sprintf("Possui token: %t   Seção crítica: %t", status.HasToken, lock.Held),
+		fmt.Sprintf("Próxima máquina: %s", t.cli.cfg.NextMachineAddr),
+		fmt.Sprintf("Quadro aguardando retorno: %s", describeOrNone(machine.GetInFlight())),
+		"",
+		pane("Contadores"),
+		fmt.Sprintf("Tokens processados: %-6d gerados: %d", status.TokensProcessed, status.TokensGenerated),
+		fmt.Sprintf("Mensagens enviadas: %-6d recebidas: %d", status.MessagesSent, status.MessagesReceived),
+		fmt.Sprintf("Erros de CRC: %-6d retransmissões: %d", status.ErrorsDetected, status.Retransmissions),
+		fmt.Sprintf("BUSY enviados: %-6d recebidos: %d", status.BusyReplies, status.BusyReceived),
+		"",
+	)
+
+	queue := machine.GetMessageQueue()
+	left = append(left, pane(fmt.Sprintf("Fila (%d/%d)", len(queue), machine.QueueCapacity())))
+	for i, msg := range queue {
+		left = append(left, fmt.Sprintf("%d. %s: %s (tentativas: %d)", i+1, msg.Destination, msg.Content, msg.Retries))
+	}
+	left = append(left, "")
+
+	inbox := machine.GetInbox()
+	left = append(left, pane(fmt.Sprintf("Buffer de recepção (%d/%d)", len(inbox), t.cli.cfg.ReceiveBufferSize)))
+	for _, msg := range inbox {
+		left = append(left, fmt.Sprintf("[%s] %s: %s", msg.Timestamp.Format("15:04:05"), msg.Origin, msg.Content))
+	}
+
+	// Coluna direita: tráfego decodificado e saída dos comandos
+	outputHeight := body * 2 / 5
+	trafficHeight := body - outputHeight - 2
+	right := []string{pane("Tráfego")}
+	right = append(right, fit(t.traffic.Last(trafficHeight), trafficHeight)...)
+	right = append(right, pane("Saída dos comandos"))
+	right = append(right, fit(t.output.Last(outputHeight), outputHeight)...)
+
+	leftWidth := t.width / 2
+	rightWidth := t.width - leftWidth - 3
+	rows := []string{title}
+	for i := 0; i < body; i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		rows = append(rows, pad(l, leftWidth)+" │ "+truncate(r, rightWidth))
+	}
+	return rows
+}
+
+// pane retorna o título de um painel
+func pane(title string) string {
+	return ansiBold + "── " + title + " ──" + ansiReset
+}
+
+// describeOrNone descreve um quadro, ou "nenhum" se vazio
+func describeOrNone(data string) string {
+	if data == "" {
+		return "nenhum"
+	}
+	return message.Describe(data)
+}
+
+// fit completa as linhas até a altura do painel
+func fit(lines []string, height int) []string {
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// visibleLen retorna o número de caracteres visíveis, ignorando as sequências ANSI
+func visibleLen(s string) int {
+	n := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				escape = false
+			}
+		case r == '\x1b':
+			escape = true
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// truncate corta o texto na largura informada, preservando as sequências ANSI
+func truncate(s string, width int) string {
+	if visibleLen(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	n := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				escape = false
+			}
+		case r == '\x1b':
+			escape = true
+		default:
+			if n == width {
+				return b.String() + ansiReset
+			}
+			n++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pad ajusta o texto exatamente à largura informada
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-visibleLen(s))
+}
+
+// terminalSize retorna as dimensões do terminal (colunas, linhas)
+// Usa o stty do sistema e, na falta dele, as variáveis COLUMNS e LINES
+func terminalSize() (int, int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	if out, err := cmd.Output(); err == nil {
+		var rows, cols int
+		if _, err := fmt.Sscan(string(out), &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+
+	width, height := tuiDefaultWidth, tuiDefaultHeight
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		width = cols
+	}
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		height = rows
+	}
+	return width, height
+}
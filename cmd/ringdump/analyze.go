@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/message"
+)
+
+// record é um quadro capturado e decodificado
+type record struct {
+	capture.Packet
+	packetType string               // Tipo do pacote (ex: 1000, 2000)
+	data       *message.DataMessage // Quadro de dados decodificado (nil para os demais tipos)
+	crcOK      bool                 // CRC do quadro de dados confere
+}
+
+// decode decodifica os quadros capturados
+func decode(packets []capture.Packet) []record {
+	records := make([]record, len(packets))
+	for i, p := range packets {
+		r := record{Packet: p, packetType: message.PacketType(p.Frame)}
+		if r.packetType == message.DataPacket {
+			if dataMsg, err := message.ParseDataPacket(p.Frame); err == nil {
+				r.data = dataMsg
+				r.crcOK = dataMsg.VerifyIntegrity()
+			}
+		}
+		records[i] = r
+	}
+	return records
+}
+
+// messageKey identifica uma mensagem de dados através das estações e das retransmissões
+// O CRC não faz parte da chave, pois o erro introduzido o altera
+type messageKey struct {
+	origin      string
+	destination string
+	content     string
+	seq         int
+}
+
+// step é um acontecimento na linha do tempo de uma mensagem
+type step struct {
+	time    time.Time
+	station string
+	text    string
+}
+
+// timeline acompanha uma mensagem desde o envio até o retorno final à estação que a enviou
+type timeline struct {
+	key             messageKey
+	sender          string        // Estação que enviou o quadro (a origem ou uma caixa postal)
+	steps           []step        // Acontecimentos, em ordem
+	first           time.Time     // Primeiro envio
+	attempts        int           // Envios pela estação (a primeira tentativa e as retransmissões)
+	retransmissions int           // Retransmissões após NAK, BUSY ou destino ausente
+	naks            int           // Retornos com NAK
+	busy            int           // Retornos com BUSY
+	badCRC          int           // Tentativas enviadas com CRC inválido
+	result          string        // Último estado de retorno (vazio se nunca retornou)
+	latency         time.Duration // Do primeiro envio ao retorno com ACK
+	returned        bool          // A tentativa atual já retornou e aguarda retransmissão
+	finished        bool          // A mensagem saiu da fila (ACK, adiada ou sem destino)
+}
+
+// analysis reúne as linhas do tempo e as estatísticas de uma captura
+type analysis struct {
+	records      []record
+	timelines    []*timeline
+	stations     []string
+	framesByType map[string]int
+	rotations    []time.Duration // Intervalos entre chegadas do token a cada estação
+}
+
+// stationKey identifica uma mensagem em uma estação
+type stationKey struct {
+	station string
+	key     messageKey
+}
+
+// analyze monta as linhas do tempo das mensagens e os tempos de volta do token
+// Os quadros devem estar em ordem de horário
+func analyze(records []record) *analysis {
+	a := &analysis{records: records, framesByType: make(map[string]int)}
+
+	open := make(map[messageKey]*timeline)  // Linha do tempo atual de cada mensagem
+	pending := make(map[stationKey]string)  // Controle do quadro recebido e ainda não repassado
+	lastToken := make(map[string]time.Time) // Última chegada do token a cada estação
+	stations := make(map[string]bool)
+
+	for _, r := range records {
+		a.framesByType[r.packetType]++
+		if !stations[r.Station] {
+			stations[r.Station] = true
+			a.stations = append(a.stations, r.Station)
+		}
+
+		if message.IsTokenPacket(r.Frame) && r.Direction == capture.Received {
+			if last, ok := lastToken[r.Station]; ok {
+				a.rotations = append(a.rotations, r.Time.Sub(last))
+			}
+			lastToken[r.Station] = r.Time
+			continue
+		}
+		if r.data == nil {
+			continue
+		}
+
+		key := messageKey{origin: r.data.Origin, destination: r.data.Destination, content: r.data.Message, seq: r.data.Seq}
+		tl := open[key]
+		at := stationKey{station: r.Station, key: key}
+
+		switch r.Direction {
+		case capture.Received:
+			if tl != nil && !tl.finished && r.Station == tl.sender {
+				tl.handleReturn(r)
+				continue
+			}
+			pending[at] = r.data.Control
+
+		case capture.Sent:
+			if control, ok := pending[at]; ok {
+				// Quadro recebido de outra estação: resposta ou repasse
+				delete(pending, at)
+				if tl == nil {
+					tl = a.newTimeline(open, key, "", r.Time)
+				}
+				tl.handlePass(r, control)
+				continue
+			}
+
+			// Envio pela própria estação (origem ou caixa postal)
+			switch {
+			case tl == nil || tl.finished || tl.sender != r.Station:
+				tl = a.newTimeline(open, key, r.Station, r.Time)
+				tl.attempts = 1
+				tl.add(r, fmt.Sprintf("enviado [%s]", r.data.Control))
+			case tl.returned:
+				tl.attempts++
+				tl.retransmissions++
+				tl.returned = false
+				tl.add(r, fmt.Sprintf("retransmitido, tentativa %d [%s]", tl.attempts, r.data.Control))
+			default:
+				// Nova solicitação na mesma tentativa (ex: guardar na caixa postal)
+				tl.add(r, fmt.Sprintf("enviado [%s]", r.data.Control))
+				continue
+			}
+			if !r.crcOK {
+				tl.badCRC++
+			}
+		}
+	}
+
+	sort.Strings(a.stations)
+	return a
+}
+
+// newTimeline inicia a linha do tempo de uma mensagem
+func (a *analysis) newTimeline(open map[messageKey]*timeline, key messageKey, sender string, t time.Time) *timeline {
+	tl := &timeline{key: key, sender: sender, first: t}
+	open[key] = tl
+	a.timelines = append(a.timelines, tl)
+	return tl
+}
+
+// add registra um acontecimento, indicando quando o quadro estava com o CRC inválido
+func (tl *timeline) add(r record, text string) {
+	if !r.crcOK {
+		text += " (CRC inválido)"
+	}
+	tl.steps = append(tl.steps, step{time: r.Time, station: r.Station, text: text})
+}
+
+// handlePass registra a passagem do quadro por outra estação
+// Se o controle mudou, a estação respondeu (ou registrou sua confirmação no broadcast)
+func (tl *timeline) handlePass(r record, received string) {
+	if r.data.Control == received {
+		tl.add(r, "repassado")
+		return
+	}
+	reply := r.data.Control
+	if r.data.HasReceipts() {
+		if receipt := r.data.Receipt(r.Station); receipt != "" {
+			reply = receipt
+		}
+	}
+	tl.add(r, "respondeu "+reply)
+}
+
+// handleReturn registra o retorno do quadro à estação que o enviou
+func (tl *timeline) handleReturn(r record) {
+	status := returnStatus(r.data)
+	tl.result = status
+
+	text := fmt.Sprintf("retornou [%s]", r.data.Control)
+	switch status {
+	case message.ControlACK:
+		tl.finished = true
+		tl.latency = r.Time.Sub(tl.first)
+		text += fmt.Sprintf(" após %s", formatDuration(tl.latency))
+	case message.ControlDeferred, message.ControlStore:
+		// Guardada por uma caixa postal, ou nenhuma caixa postal a guardou
+		tl.finished = true
+	case message.ControlNAK:
+		tl.naks++
+		tl.returned = true
+	case message.ControlBusy:
+		tl.busy++
+		tl.returned = true
+	case message.ControlMachineNotExists:
+		// A origem ainda pode pedir a uma caixa postal que guarde a mensagem
+		if message.IsRelayControl(r.data.Control) {
+			tl.returned = true
+		}
+	}
+	tl.add(r, text)
+}
+
+// returnStatus resume o controle de um quadro que retornou: ACK, NAK, BUSY, adiado, etc.
+// No broadcast, NAK prevalece sobre BUSY, que prevalece sobre ACK
+func returnStatus(dataMsg *message.DataMessage) string {
+	control := dataMsg.Control
+	if message.IsRelayControl(control) {
+		if status := message.RelayStatus(control); status != "" {
+			return status
+		}
+		return message.ControlMachineNotExists
+	}
+	if !dataMsg.HasReceipts() {
+		return control
+	}
+
+	receipts := dataMsg.Receipts()
+	if len(receipts) == 0 {
+		return message.ControlMachineNotExists
+	}
+	status := message.ControlACK
+	for _, r := range receipts {
+		switch r.Status {
+		case message.ControlNAK:
+			return message.ControlNAK
+		case message.ControlBusy:
+			status = message.ControlBusy
+		}
+	}
+	return status
+}
+
+// formatDuration arredonda a duração para exibição
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"ring-network/pkg/capture"
+)
+
+// main decodifica capturas de quadros da rede em anel (pcapng ou JSONL)
+// Várias capturas (ex: uma por estação) são combinadas pela ordem dos horários
+func main() {
+	listFrames := flag.Bool("quadros", false, "Lista cada quadro decodificado")
+	summaryOnly := flag.Bool("resumo", false, "Exibe apenas o resumo, sem as linhas do tempo")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Uso: go run ./cmd/ringdump [--quadros] [--resumo] <captura> [captura...]")
+		fmt.Fprintln(os.Stderr, "Exemplo: go run ./cmd/ringdump alice.pcapng bob.jsonl")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var packets []capture.Packet
+	for _, path := range flag.Args() {
+		read, err := capture.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao ler %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		packets = append(packets, read...)
+	}
+	sort.SliceStable(packets, func(i, j int) bool {
+		return packets[i].Time.Before(packets[j].Time)
+	})
+
+	a := analyze(decode(packets))
+
+	if *listFrames {
+		printFrames(os.Stdout, a)
+	}
+	if !*summaryOnly {
+		printTimelines(os.Stdout, a)
+	}
+	printSummary(os.Stdout, a)
+}
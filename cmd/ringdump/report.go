@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/message"
+)
+
+// timeLayout é o formato dos horários exibidos
+const timeLayout = "15:04:05.000000"
+
+// printFrames lista cada quadro decodificado, com o resultado da verificação do CRC
+func printFrames(out io.Writer, a *analysis) {
+	width := stationWidth(a)
+
+	fmt.Fprintln(out, "=== Quadros ===")
+	for _, r := range a.records {
+		arrow := "←"
+		if r.Direction == capture.Sent {
+			arrow = "→"
+		}
+		line := fmt.Sprintf("%s  %-*s %s %-21s %s", r.Time.Format(timeLayout), width, r.Station, arrow, r.Peer, message.Describe(r.Frame))
+		if r.data != nil {
+			if r.crcOK {
+				line += "  CRC ok"
+			} else {
+				line += "  CRC INVÁLIDO"
+			}
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintln(out)
+}
+
+// printTimelines exibe a linha do tempo de cada mensagem: envio → entrega → resposta → retorno
+func printTimelines(out io.Writer, a *analysis) {
+	width := stationWidth(a)
+
+	fmt.Fprintln(out, "=== Linha do tempo das mensagens ===")
+	if len(a.timelines) == 0 {
+		fmt.Fprintln(out, "Nenhum quadro de dados capturado.")
+	}
+	for i, tl := range a.timelines {
+		header := fmt.Sprintf("#%d %s → %s %q", i+1, tl.key.origin, tl.key.destination, tl.key.content)
+		if tl.key.seq > 0 {
+			header += fmt.Sprintf(" seq=%d", tl.key.seq)
+		}
+		if tl.sender != "" && tl.sender != tl.key.origin {
+			header += " (enviada por " + tl.sender + ")"
+		}
+		fmt.Fprintln(out, header)
+
+		for _, s := range tl.steps {
+			fmt.Fprintf(out, "  %s  %-*s %s\n", s.time.Format(timeLayout), width, s.station, s.text)
+		}
+		fmt.Fprintf(out, "  resultado: %s\n\n", tl.describeResult())
+	}
+}
+
+// describeResult resume o desfecho de uma mensagem
+func (tl *timeline) describeResult() string {
+	var result string
+	switch {
+	case tl.result == "":
+		result = "sem retorno na captura"
+	case tl.result == message.ControlACK:
+		result = fmt.Sprintf("entregue (ACK) em %s", formatDuration(tl.latency))
+	case tl.result == message.ControlDeferred:
+		result = "adiada (guardada na caixa postal)"
+	case !tl.finished && tl.result == message.ControlMachineNotExists:
+		result = "pendente, destino ausente"
+	case !tl.finished:
+		result = "pendente após " + tl.result
+	default:
+		result = "destino inexistente ou desligado"
+	}
+	if tl.attempts > 0 {
+		result += fmt.Sprintf(", %d tentativa(s)", tl.attempts)
+	}
+	return result
+}
+
+// printSummary exibe as estatísticas da captura
+func printSummary(out io.Writer, a *analysis) {
+	fmt.Fprintln(out, "=== Resumo ===")
+	fmt.Fprintf(out, "Estações: %s\n", strings.Join(a.stations, ", "))
+
+	// Quadros por tipo, na ordem dos identificadores
+	types := make([]string, 0, len(a.framesByType))
+	for packetType := range a.framesByType {
+		types = append(types, packetType)
+	}
+	sort.Strings(types)
+	counts := make([]string, len(types))
+	for i, packetType := range types {
+		counts[i] = fmt.Sprintf("%s %d", message.PacketName(packetType), a.framesByType[packetType])
+	}
+	fmt.Fprintf(out, "Quadros capturados: %d (%s)\n", len(a.records), strings.Join(counts, ", "))
+
+	var delivered, deferred, missing, pending, attempts, retransmissions, naks, busy, badCRC int
+	var latencies []time.Duration
+	for _, tl := range a.timelines {
+		switch {
+		case tl.result == message.ControlACK:
+			delivered++
+			latencies = append(latencies, tl.latency)
+		case tl.result == message.ControlDeferred:
+			deferred++
+		case tl.finished:
+			missing++
+		default:
+			pending++
+		}
+		attempts += tl.attempts
+		retransmissions += tl.retransmissions
+		naks += tl.naks
+		busy += tl.busy
+		badCRC += tl.badCRC
+	}
+
+	fmt.Fprintf(out, "Mensagens: %d (entregues %d, adiadas %d, sem destino %d, pendentes %d)\n",
+		len(a.timelines), delivered, deferred, missing, pending)
+	fmt.Fprintf(out, "Envios: %d, retransmissões: %d (taxa %s), NAK %d, BUSY %d, enviados com CRC inválido %d\n",
+		attempts, retransmissions, percent(retransmissions, attempts), naks, busy, badCRC)
+	fmt.Fprintf(out, "Latência de entrega (envio → ACK): %s\n", durationStats(latencies))
+	fmt.Fprintf(out, "Volta do token: %s\n", durationStats(a.rotations))
+}
+
+// durationStats resume uma série de durações (mínima, média, máxima)
+func durationStats(values []time.Duration) string {
+	if len(values) == 0 {
+		return "sem amostras"
+	}
+	minimum, maximum, total := values[0], values[0], time.Duration(0)
+	for _, v := range values {
+		minimum = min(minimum, v)
+		maximum = max(maximum, v)
+		total += v
+	}
+	average := total / time.Duration(len(values))
+	return fmt.Sprintf("mín %s, média %s, máx %s (%d amostra(s))",
+		formatDuration(minimum), formatDuration(average), formatDuration(maximum), len(values))
+}
+
+// percent formata a fração como porcentagem
+func percent(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
+
+// stationWidth retorna a largura do maior nome de estação, para alinhar as colunas
+func stationWidth(a *analysis) int {
+	width := 0
+	for _, station := range a.stations {
+		width = max(width, len([]rune(station)))
+	}
+	return width
+}
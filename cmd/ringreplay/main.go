@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/logging"
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+	"ring-network/pkg/replay"
+	"ring-network/pkg/sim"
+)
+
+// main reinjeta os quadros de capturas (pcapng ou JSONL) em um anel em execução ou em um anel simulado
+func main() {
+	station := flag.String("estacao", "", "Reproduz apenas os quadros recebidos por esta estação (requer --para, exceto com --simular)")
+	target := flag.String("para", "", "Envia todos os quadros a este endereço (IP:porta)")
+	addresses := flag.String("mapa", "", "Troca endereços de destino da captura: original=novo[,original=novo...]")
+	speed := flag.Float64("velocidade", 1, "Fator de velocidade em relação à captura (2 = duas vezes mais rápido; 0 = sem espera)")
+	types := flag.String("tipos", "", "Tipos de pacote reproduzidos, separados por vírgula (ex: 2000,1000); vazio = todos")
+	port := flag.Int("porta", 0, "Porta UDP local de onde os quadros são enviados (0 = qualquer)")
+	listOnly := flag.Bool("listar", false, "Apenas lista os quadros que seriam reinjetados")
+	ring := flag.String("simular", "", "Reproduz em um anel simulado com estas estações, na ordem do anel (ex: Alice,Bob,Carol)")
+	hold := flag.Duration("posse", time.Second, "Tempo de posse do token no anel simulado (tempo_token da captura)")
+	drain := flag.Duration("drenar", 10*time.Second, "Tempo simulado após o último quadro reinjetado")
+	logLevel := flag.String("log", "info", "Nível do log das máquinas simuladas, na saída de erro (debug, info, aviso, erro)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Uso: go run ./cmd/ringreplay [opções] <captura> [captura...]")
+		fmt.Fprintln(os.Stderr, "Exemplo: go run ./cmd/ringreplay --estacao Alice --para 127.0.0.1:6000 alice.pcapng")
+		fmt.Fprintln(os.Stderr, "Exemplo: go run ./cmd/ringreplay --simular Alice,Bob,Carol alice.jsonl bob.jsonl carol.jsonl")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	sel := replay.Selection{Station: *station, Target: *target}
+	var err error
+	if sel.Addresses, err = parseAddresses(*addresses); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+	if *types != "" {
+		sel.Types = make(map[string]bool)
+		for _, t := range strings.Split(*types, ",") {
+			sel.Types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	var packets []capture.Packet
+	for _, path := range flag.Args() {
+		read, err := capture.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao ler %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		packets = append(packets, read...)
+	}
+	sort.SliceStable(packets, func(i, j int) bool {
+		return packets[i].Time.Before(packets[j].Time)
+	})
+
+	// No anel simulado os endereços são os nomes das estações
+	var names []string
+	if *ring != "" {
+		for _, name := range strings.Split(*ring, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		for address, name := range ringAddresses(packets, names) {
+			if _, ok := sel.Addresses[address]; !ok {
+				sel.Addresses[address] = name
+			}
+		}
+		if sel.Station != "" && sel.Target == "" {
+			sel.Target = sel.Station
+		}
+	}
+
+	frames, err := replay.Select(packets, sel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+	if len(frames) == 0 {
+		fmt.Println("Nenhum quadro selecionado para reprodução.")
+		return
+	}
+
+	if *listOnly {
+		for _, frame := range frames {
+			printFrame(frame)
+		}
+		fmt.Printf("%d quadros, duração original %s\n", len(frames), frames[len(frames)-1].Offset)
+		return
+	}
+
+	if *ring != "" {
+		if err := simulate(names, *hold, *drain, *logLevel, frames, *speed); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	transport, err := network.NewUDPTransport(*port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+	defer transport.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	fmt.Printf("Reproduzindo %d quadros (velocidade %gx)\n", len(frames), *speed)
+	started := time.Now()
+	sent := 0
+	err = replay.Run(ctx, transport, frames, *speed, func(frame replay.Frame) {
+		sent++
+		printFrame(frame)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reprodução interrompida após %d quadros: %v\n", sent, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d quadros reproduzidos em %s\n", sent, time.Since(started).Round(time.Millisecond))
+}
+
+// simulate reproduz os quadros em um anel simulado sob um relógio virtual e exibe o estado final das estações
+// O anel começa sem token: o token vem da reprodução
+func simulate(names []string, hold, drain time.Duration, logLevel string, frames []replay.Frame, speed float64) error {
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	logger, _, err := logging.New(logging.Options{Level: level, Mirror: os.Stderr})
+	if err != nil {
+		return err
+	}
+
+	cfg := sim.DefaultConfig()
+	cfg.Stations = len(names)
+	cfg.Names = names
+	cfg.TokenHold = hold
+	cfg.Logger = logger
+	ring, err := sim.NewRing(cfg)
+	if err != nil {
+		return err
+	}
+	defer ring.Stop()
+	if err := ring.Begin(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Reproduzindo %d quadros no anel simulado %s (velocidade %gx)\n", len(frames), strings.Join(names, " → "), speed)
+	last, err := ring.Replay(frames, speed, printFrame)
+	if err != nil {
+		return err
+	}
+	ring.Sim.RunUntil(last.Add(drain))
+
+	fmt.Printf("%d quadros reproduzidos em %s simulados (%d eventos)\n", len(frames), ring.Sim.Elapsed().Round(time.Millisecond), ring.Sim.Processed())
+	for _, station := range ring.Stations {
+		status := station.Machine.GetStatus()
+		fmt.Printf("%-10s token: %-5t fila: %d | enviadas: %d, recebidas: %d, erros: %d, retransmissões: %d\n",
+			station.Name, status.HasToken, status.QueueSize, status.MessagesSent, status.MessagesReceived,
+			status.ErrorsDetected, status.Retransmissions)
+	}
+	return nil
+}
+
+// ringAddresses associa os endereços das capturas aos nomes das estações do anel simulado:
+// o token enviado por uma estação vai para o endereço da seguinte
+func ringAddresses(packets []capture.Packet, names []string) map[string]string {
+	next := make(map[string]string, len(names))
+	for i, name := range names {
+		next[name] = names[(i+1)%len(names)]
+	}
+
+	addresses := make(map[string]string)
+	for _, p := range packets {
+		if name, ok := next[p.Station]; ok && p.Direction == capture.Sent && message.IsTokenPacket(p.Frame) {
+			addresses[p.Peer] = name
+		}
+	}
+	return addresses
+}
+
+// printFrame exibe um quadro reinjetado com o instante relativo na captura original
+func printFrame(frame replay.Frame) {
+	fmt.Printf("[+%9.3fs] → %-21s %s\n", frame.Offset.Seconds(), frame.Address, message.Describe(frame.Packet.Frame))
+}
+
+// parseAddresses interpreta a lista original=novo de --mapa
+func parseAddresses(value string) (map[string]string, error) {
+	addresses := make(map[string]string)
+	if value == "" {
+		return addresses, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		original, replacement, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || original == "" || replacement == "" {
+			return nil, fmt.Errorf("item inválido em --mapa (esperado original=novo): %s", item)
+		}
+		addresses[original] = replacement
+	}
+	return addresses, nil
+}
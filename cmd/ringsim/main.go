@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"ring-network/pkg/sim"
+)
+
+// main simula um anel de muitas estações sob um relógio virtual e compara as curvas com as fórmulas
+func main() {
+	cfg := sim.DefaultConfig()
+	opts := sim.DefaultOptions()
+
+	flag.IntVar(&cfg.Stations, "estacoes", cfg.Stations, "Número de estações do anel")
+	bandwidth := flag.Float64("banda", cfg.Bandwidth/1e6, "Taxa de transmissão de cada enlace, em Mbit/s")
+	flag.DurationVar(&cfg.Propagation, "propagacao", cfg.Propagation, "Atraso de propagação de cada enlace")
+	flag.DurationVar(&cfg.TokenHold, "posse", cfg.TokenHold, "Tempo de posse do token em cada estação antes de transmitir")
+	flag.IntVar(&cfg.QueueCapacity, "fila", cfg.QueueCapacity, "Capacidade da fila de envio de cada estação")
+	flag.Float64Var(&cfg.ErrorProbability, "erro", cfg.ErrorProbability, "Probabilidade de erro em cada transmissão")
+	flag.Int64Var(&cfg.Seed, "semente", cfg.Seed, "Semente dos sorteios (tráfego e erros)")
+	loads := flag.String("cargas", formatLoads(opts.Loads), "Cargas oferecidas, em fração da saturação, separadas por vírgula")
+	flag.IntVar(&opts.Messages, "mensagens", opts.Messages, "Mensagens medidas em cada carga")
+	flag.IntVar(&opts.Size, "tamanho", opts.Size, "Tamanho do conteúdo das mensagens, em bytes")
+	csvFile := flag.String("csv", "", "Grava as curvas neste arquivo CSV")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Uso: go run ./cmd/ringsim [opções]")
+		fmt.Fprintln(os.Stderr, "Exemplo: go run ./cmd/ringsim --estacoes 300 --banda 4 --propagacao 20us --csv curvas.csv")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg.Bandwidth = *bandwidth * 1e6
+	var err error
+	if opts.Loads, err = parseLoads(*loads); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+
+	model := sim.NewModel(cfg, opts.Size)
+	points, err := sim.Sweep(cfg, opts, func(p sim.Point) {
+		fmt.Fprintf(os.Stderr, "carga %.2f: %d eventos, %v simulados em %v\n",
+			p.Load, p.Events, p.Simulated.Round(1e6), p.Elapsed.Round(1e6))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+		os.Exit(1)
+	}
+	sim.WriteReport(os.Stdout, cfg, model, points)
+
+	if *csvFile != "" {
+		file, err := os.Create(*csvFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erro ao criar %s: %v\n", *csvFile, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		if err := sim.WriteCSV(file, model, points); err != nil {
+			fmt.Fprintf(os.Stderr, "Erro: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Curvas gravadas em %s\n", *csvFile)
+	}
+}
+
+// parseLoads interpreta a lista de cargas de --cargas
+func parseLoads(value string) ([]float64, error) {
+	var loads []float64
+	for _, item := range strings.Split(value, ",") {
+		load, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return nil, fmt.Errorf("carga inválida em --cargas: %s", item)
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
+
+// formatLoads formata a lista de cargas padrão
+func formatLoads(loads []float64) string {
+	items := make([]string, len(loads))
+	for i, load := range loads {
+		items[i] = strconv.FormatFloat(load, 'g', -1, 64)
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// Inbox implementa o buffer de recepção thread-safe com tamanho máximo
+// Guarda as mensagens entregues a esta máquina até que a aplicação as leia
+type Inbox struct {
+	messages []*message.ReceivedMessage // Mensagens recebidas ainda não lidas
+	mutex    sync.RWMutex               // Mutex para acesso concorrente
+	maxSize  int                        // Tamanho máximo do buffer
+	now      func() time.Time           // Relógio usado para o horário de recebimento
+}
+
+// NewInbox cria um novo buffer de recepção com o tamanho máximo especificado
+func NewInbox(maxSize int) *Inbox {
+	return &Inbox{
+		messages: make([]*message.ReceivedMessage, 0, maxSize),
+		maxSize:  maxSize,
+		now:      time.Now,
+	}
+}
+
+// SetClock define o relógio usado para o horário de recebimento das mensagens
+func (ib *Inbox) SetClock(now func() time.Time) {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	ib.now = now
+}
+
+// Add adiciona uma mensagem recebida ao buffer
+// Retorna erro se o buffer estiver cheio
+func (ib *Inbox) Add(origin, content string) error {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	if len(ib.messages) >= ib.maxSize {
+		return fmt.Errorf("buffer de recepção cheio (máximo: %d mensagens)", ib.maxSize)
+	}
+
+	msg := message.NewReceivedMessage(origin, content)
+	msg.Timestamp = ib.now()
+	ib.messages = append(ib.messages, msg)
+	return nil
+}
+
+// Receive remove e retorna a mensagem mais antiga do buffer
+// Retorna nil se o buffer estiver vazio
+func (ib *Inbox) Receive() *message.ReceivedMessage {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	if len(ib.messages) == 0 {
+		return nil
+	}
+
+	msg := ib.messages[0]
+	ib.messages = ib.messages[1:]
+
+	return msg
+}
+
+// ReceiveAll remove e retorna todas as mensagens do buffer
+func (ib *Inbox) ReceiveAll() []*message.ReceivedMessage {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	result := make([]*message.ReceivedMessage, len(ib.messages))
+	copy(result, ib.messages)
+	ib.messages = ib.messages[:0]
+
+	return result
+}
+
+// GetAll retorna uma cópia de todas as mensagens sem removê-las
+func (ib *Inbox) GetAll() []*message.ReceivedMessage {
+	ib.mutex.RLock()
+	defer ib.mutex.RUnlock()
+
+	result := make([]*message.ReceivedMessage, len(ib.messages))
+	copy(result, ib.messages)
+
+	return result
+}
+
+// Size retorna o número atual de mensagens no buffer
+func (ib *Inbox) Size() int {
+	ib.mutex.RLock()
+	defer ib.mutex.RUnlock()
+
+	return len(ib.messages)
+}
+
+// Capacity retorna o tamanho máximo do buffer
+func (ib *Inbox) Capacity() int {
+	return ib.maxSize
+}
+
+// IsFull verifica se o buffer está cheio
+func (ib *Inbox) IsFull() bool {
+	return ib.Size() >= ib.maxSize
+}
+
+// String retorna uma representação em string do buffer de recepção
+func (ib *Inbox) String() string {
+	ib.mutex.RLock()
+	defer ib.mutex.RUnlock()
+
+	return fmt.Sprintf("Inbox{Size: %d/%d, Messages: %v}",
+		len(ib.messages), ib.maxSize, ib.messages)
+}
@@ -3,6 +3,7 @@ package queue
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"ring-network/pkg/message"
 )
@@ -12,6 +13,7 @@ type MessageQueue struct {
 	messages []*message.QueuedMessage // Slice de mensagens na fila
 	mutex    sync.RWMutex             // Mutex para acesso concorrente
 	maxSize  int                      // Tamanho máximo da fila
+	now      func() time.Time         // Relógio usado para o horário das mensagens
 }
 
 // NewMessageQueue cria uma nova fila de mensagens com o tamanho máximo especificado
@@ -19,9 +21,18 @@ func NewMessageQueue(maxSize int) *MessageQueue {
 	return &MessageQueue{
 		messages: make([]*message.QueuedMessage, 0, maxSize),
 		maxSize:  maxSize,
+		now:      time.Now,
 	}
 }
 
+// SetClock define o relógio usado para o horário das mensagens enfileiradas
+func (mq *MessageQueue) SetClock(now func() time.Time) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+
+	mq.now = now
+}
+
 // Enqueue adiciona uma nova mensagem à fila
 // Retorna erro se a fila estiver cheia
 func (mq *MessageQueue) Enqueue(destination, content string) error {
@@ -33,11 +44,28 @@ func (mq *MessageQueue) Enqueue(destination, content string) error {
 	}
 
 	queuedMsg := message.NewQueuedMessage(destination, content)
+	queuedMsg.Timestamp = mq.now()
 	mq.messages = append(mq.messages, queuedMsg)
 
 	return nil
 }
 
+// EnqueueMessage adiciona uma mensagem já criada à fila
+// Usado quando a mensagem tem atributos além de destino e conteúdo
+// Retorna erro se a fila estiver cheia
+func (mq *MessageQueue) EnqueueMessage(msg *message.QueuedMessage) error {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+
+	if len(mq.messages) >= mq.maxSize {
+		return fmt.Errorf("fila cheia (máximo: %d mensagens)", mq.maxSize)
+	}
+
+	mq.messages = append(mq.messages, msg)
+
+	return nil
+}
+
 // Dequeue remove e retorna a primeira mensagem da fila
 // Retorna nil se a fila estiver vazia
 func (mq *MessageQueue) Dequeue() *message.QueuedMessage {
@@ -75,6 +103,11 @@ func (mq *MessageQueue) Size() int {
 	return len(mq.messages)
 }
 
+// Capacity retorna o tamanho máximo da fila
+func (mq *MessageQueue) Capacity() int {
+	return mq.maxSize
+}
+
 // IsEmpty verifica se a fila está vazia
 func (mq *MessageQueue) IsEmpty() bool {
 	return mq.Size() == 0
@@ -116,6 +149,39 @@ func (mq *MessageQueue) IncrementRetries() {
 	}
 }
 
+// SetFirstMessageDelivered registra as estações que já confirmaram a primeira mensagem
+// Usado em broadcasts para retransmitir apenas às estações que não confirmaram
+func (mq *MessageQueue) SetFirstMessageDelivered(stations []string) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+
+	if len(mq.messages) > 0 {
+		mq.messages[0].Delivered = stations
+	}
+}
+
+// SetFirstMessageSeq registra o número de sequência atribuído à primeira mensagem
+// Usado na difusão com ordem total para que retransmissões mantenham o mesmo número
+func (mq *MessageQueue) SetFirstMessageSeq(seq int) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+
+	if len(mq.messages) > 0 {
+		mq.messages[0].Seq = seq
+	}
+}
+
+// SetFirstMessageSent registra o momento da primeira transmissão da primeira mensagem
+// Usado para medir a espera na fila e o tempo até a confirmação
+func (mq *MessageQueue) SetFirstMessageSent(t time.Time) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+
+	if len(mq.messages) > 0 {
+		mq.messages[0].Sent = t
+	}
+}
+
 // GetFirstMessageRetries retorna o número de tentativas da primeira mensagem
 func (mq *MessageQueue) GetFirstMessageRetries() int {
 	mq.mutex.RLock()
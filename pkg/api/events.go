@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"ring-network/pkg/network"
+)
+
+// eventBuffer é o número de eventos retidos para um cliente de /stream lento
+const eventBuffer = 256
+
+// countEvent conta os eventos da máquina por tipo, para as métricas
+func (s *Server) countEvent(event network.Event) {
+	s.eventMutex.Lock()
+	defer s.eventMutex.Unlock()
+
+	s.eventCounts[event.Type]++
+}
+
+// eventTotals retorna uma cópia da contagem de eventos por tipo
+func (s *Server) eventTotals() map[string]float64 {
+	s.eventMutex.Lock()
+	defer s.eventMutex.Unlock()
+
+	totals := make(map[string]float64, len(s.eventCounts))
+	for eventType, count := range s.eventCounts {
+		totals[string(eventType)] = float64(count)
+	}
+	return totals
+}
+
+// handleStream envia os eventos da máquina como Server-Sent Events, um objeto JSON por evento
+// Com ?tipo=ack,nak apenas os tipos informados são enviados
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, response{Error: "streaming não suportado"})
+		return
+	}
+
+	filter := make(map[network.EventType]bool)
+	if types := r.URL.Query().Get("tipo"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter[network.EventType(strings.TrimSpace(t))] = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	events, cancel := s.machine.Subscribe(eventBuffer)
+	defer cancel()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.machine.Done():
+			return
+		case event := <-events:
+			if len(filter) > 0 && !filter[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.machine.Log(slog.LevelError, "Erro ao codificar evento: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"ring-network/pkg/metrics"
+)
+
+// handleMetrics expõe os contadores da máquina no formato de texto do Prometheus
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	status := s.machine.GetStatus()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	pw := metrics.NewWriter(w, map[string]string{"machine": status.MachineName})
+
+	pw.Counter("ring_tokens_processed_total", "Tokens recebidos por esta máquina.", float64(status.TokensProcessed))
+	pw.Counter("ring_tokens_generated_total", "Tokens gerados por esta máquina (inicial, manual ou pelo watchdog).", float64(status.TokensGenerated))
+	pw.Counter("ring_messages_sent_total", "Quadros de dados transmitidos por esta máquina.", float64(status.MessagesSent))
+	pw.Counter("ring_messages_received_total", "Quadros de dados entregues a esta máquina.", float64(status.MessagesReceived))
+	pw.Counter("ring_crc_errors_total", "Quadros recebidos com erro de CRC.", float64(status.ErrorsDetected))
+	pw.Counter("ring_retransmissions_total", "Retransmissões por NAK.", float64(status.Retransmissions))
+	pw.Counter("ring_busy_replies_total", "Quadros recusados por buffer de recepção cheio.", float64(status.BusyReplies))
+	pw.Counter("ring_busy_received_total", "Respostas BUSY recebidas de destinos ocupados.", float64(status.BusyReceived))
+	pw.Counter("ring_messages_deferred_total", "Mensagens guardadas por uma caixa postal.", float64(status.MessagesDeferred))
+	pw.Gauge("ring_queue_depth", "Mensagens na fila de envio.", float64(status.QueueSize))
+	pw.Gauge("ring_inbox_depth", "Mensagens no buffer de recepção.", float64(status.InboxSize))
+	pw.Gauge("ring_has_token", "1 se esta máquina possui o token.", boolValue(status.HasToken))
+	pw.CounterSeries("ring_events_total", "Eventos publicados pela máquina, por tipo.", "type", s.eventTotals())
+	pw.Histogram("ring_token_rotation_seconds", "Tempo entre chegadas consecutivas do token a esta máquina.", "",
+		map[string]metrics.HistogramSnapshot{"": s.machine.GetTokenRotation()})
+	pw.Histogram("ring_delivery_latency_seconds", "Tempo do enfileiramento à confirmação de entrega, por destino.", "destination",
+		s.machine.GetDeliveryLatency())
+
+	if err := pw.Err(); err != nil {
+		s.machine.Log(slog.LevelError, "Erro ao escrever métricas: %v", err)
+	}
+}
+
+// boolValue converte um valor lógico para o formato das métricas
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+)
+
+// Server expõe a administração de uma máquina via HTTP/JSON
+// Permite que scripts e painéis controlem a máquina sem o terminal
+//
+//	GET  /status     - status da máquina
+//	GET  /queue      - fila de mensagens a enviar
+//	GET  /inbox      - buffer de recepção (?ler=true remove as mensagens lidas)
+//	POST /send       - {"destino": "Bob", "mensagem": "oi"}
+//	POST /broadcast  - {"mensagem": "oi"}
+//	POST /token      - gera um novo token
+//	GET  /metrics    - contadores e histogramas no formato do Prometheus
+//	GET  /stream     - eventos da máquina via Server-Sent Events (?tipo=ack,nak filtra os tipos)
+type Server struct {
+	machine     *network.Machine
+	mux         *http.ServeMux
+	server      *http.Server
+	eventCounts map[network.EventType]int // Eventos da máquina por tipo, para as métricas
+	stopEvents  func()                    // Cancela a contagem de eventos
+	eventMutex  sync.Mutex
+}
+
+// sendRequest é o corpo aceito por /send e /broadcast
+type sendRequest struct {
+	Destination string `json:"destino"`
+	Message     string `json:"mensagem"`
+}
+
+// response é a resposta das operações que não retornam dados
+type response struct {
+	Result string `json:"resultado,omitempty"`
+	Error  string `json:"erro,omitempty"`
+}
+
+// NewServer cria o servidor de administração da máquina
+func NewServer(machine *network.Machine) *Server {
+	s := &Server{
+		machine:     machine,
+		mux:         http.NewServeMux(),
+		eventCounts: make(map[network.EventType]int),
+	}
+	s.stopEvents = machine.OnEvent(s.countEvent)
+
+	s.mux.HandleFunc("GET /status", s.handleStatus)
+	s.mux.HandleFunc("GET /queue", s.handleQueue)
+	s.mux.HandleFunc("GET /inbox", s.handleInbox)
+	s.mux.HandleFunc("POST /send", s.handleSend)
+	s.mux.HandleFunc("POST /broadcast", s.handleBroadcast)
+	s.mux.HandleFunc("POST /token", s.handleToken)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+	s.mux.HandleFunc("GET /stream", s.handleStream)
+
+	return s
+}
+
+// Handle registra uma rota adicional no servidor (ex: métricas, painel)
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start passa a atender no endereço informado (ex: ":8080")
+// Retorna erro se o endereço não puder ser usado; o atendimento segue em segundo plano
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar servidor HTTP: %v", err)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.machine.Log(slog.LevelError, "Servidor HTTP encerrado: %v", err)
+		}
+	}()
+
+	s.machine.Logf("Servidor HTTP de administração em %s", listener.Addr())
+	return nil
+}
+
+// Stop encerra o servidor, aguardando as requisições em andamento
+func (s *Server) Stop(ctx context.Context) error {
+	s.stopEvents()
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
+
+// handleStatus retorna o status da máquina
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.machine.GetStatus())
+}
+
+// handleStats retorna as estatísticas de tempo da máquina
+// Com ?formato=csv a resposta é o CSV gravado pelo comando stats
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := s.machine.GetTimingStats()
+	if r.URL.Query().Get("formato") != "csv" {
+		writeJSON(w, http.StatusOK, stats)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if err := stats.WriteCSV(w); err != nil {
+		s.machine.Log(slog.LevelError, "Erro ao escrever estatísticas: %v", err)
+	}
+}
+
+// handleQueue retorna as mensagens na fila de envio
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.machine.GetMessageQueue())
+}
+
+// handleInbox retorna as mensagens do buffer de recepção
+// Com ?ler=true as mensagens são removidas, liberando o buffer como o comando inbox
+func (s *Server) handleInbox(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("ler") == "true" {
+		writeJSON(w, http.StatusOK, s.machine.ReadInbox())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.machine.GetInbox())
+}
+
+// handleSend adiciona uma mensagem unicast (ou para um grupo) à fila
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSendRequest(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Destination) == "" {
+		writeJSON(w, http.StatusBadRequest, response{Error: "destino não informado"})
+		return
+	}
+	s.enqueue(w, req.Destination, req.Message)
+}
+
+// handleBroadcast adiciona uma mensagem broadcast à fila
+func (s *Server) handleBroadcast(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSendRequest(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+	s.enqueue(w, message.BroadcastAddress, req.Message)
+}
+
+// handleToken gera um novo token
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := s.machine.GenerateToken(); err != nil {
+		writeJSON(w, http.StatusConflict, response{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, response{Result: "novo token gerado e enviado"})
+}
+
+// enqueue adiciona a mensagem à fila e responde com o resultado
+func (s *Server) enqueue(w http.ResponseWriter, destination, content string) {
+	if err := s.machine.QueueMessage(destination, content); err != nil {
+		// A fila cheia é a única falha do enfileiramento
+		writeJSON(w, http.StatusServiceUnavailable, response{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, response{Result: fmt.Sprintf("mensagem adicionada à fila para %s", destination)})
+}
+
+// decodeSendRequest lê o corpo JSON de /send e /broadcast
+func decodeSendRequest(r *http.Request) (sendRequest, error) {
+	var req sendRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 64*1024))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return req, fmt.Errorf("corpo JSON inválido: %v", err)
+	}
+	if req.Message == "" {
+		return req, fmt.Errorf("mensagem não informada")
+	}
+	return req, nil
+}
+
+// writeJSON escreve a resposta JSON com o código de status informado
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,288 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/metrics"
+	"ring-network/pkg/network"
+)
+
+// Result reúne as medidas de um benchmark
+type Result struct {
+	Options        Options
+	Elapsed        time.Duration                // Geração e drenagem
+	Offered        int                          // Mensagens geradas
+	Rejected       int                          // Mensagens recusadas pela fila cheia
+	Delivered      int                          // Mensagens confirmadas (ACK)
+	Pending        int                          // Mensagens aceitas e não confirmadas ao final
+	NAKs           int                          // Retornos com NAK (retransmissões)
+	Busy           int                          // Retornos com BUSY
+	NotExists      int                          // Retornos com destino inexistente
+	Throughput     float64                      // Mensagens confirmadas por segundo
+	Latency        metrics.SummarySnapshot      // Do enfileiramento à confirmação, em segundos
+	Histogram      metrics.HistogramSnapshot    // Distribuição da latência, em segundos
+	TokenRotation  metrics.SummarySnapshot      // Tempo entre chegadas do token durante o benchmark, em segundos
+	TokenPasses    int                          // Passagens do token por esta máquina
+	Transmissions  int                          // Quadros de dados transmitidos (inclui retransmissões)
+	PerDestination map[string]DestinationResult // Mensagens por destino
+}
+
+// DestinationResult conta as mensagens de um destino
+type DestinationResult struct {
+	Offered   int // Mensagens geradas para o destino
+	Delivered int // Mensagens confirmadas
+}
+
+// Utilization é a fração das passagens do token em que esta máquina transmitiu um quadro de dados
+// Cada passagem leva no máximo um quadro: 100% indica que a máquina está saturada
+func (r *Result) Utilization() float64 {
+	if r.TokenPasses == 0 {
+		return 0
+	}
+	return float64(r.Transmissions) / float64(r.TokenPasses)
+}
+
+// Capacity é a vazão máxima desta máquina: uma mensagem por volta do token
+// Retorna 0 se o token não deu nenhuma volta durante o benchmark
+func (r *Result) Capacity() float64 {
+	if r.TokenRotation.Avg == 0 {
+		return 0
+	}
+	return 1 / r.TokenRotation.Avg
+}
+
+// Run gera o tráfego pela fila da máquina (QueueMessage) e mede as confirmações
+// Após a duração, aguarda as confirmações das mensagens aceitas até o tempo de drenagem
+// Se o contexto for cancelado, retorna o resultado parcial junto com o erro do contexto
+func Run(ctx context.Context, machine *network.Machine, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	generator := NewGenerator(opts, rand.New(rand.NewSource(seed)))
+
+	t := newTracker()
+	stop := machine.OnEvent(t.handle)
+	defer stop()
+	before := machine.GetStatus()
+
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	next := start
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	var err error
+generate:
+	for n := 1; ; n++ {
+		interval, destination := generator.Next()
+		next = next.Add(interval)
+		if !next.Before(deadline) {
+			break
+		}
+		if wait := time.Until(next); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break generate
+			case <-timer.C:
+			}
+		}
+
+		content := messageContent(n, opts.Size)
+		t.add(destination, content)
+		if machine.QueueMessage(destination, content) != nil {
+			t.reject(destination, content)
+		}
+	}
+
+	// Drenagem: aguarda as confirmações das mensagens que continuam na fila
+	if err == nil {
+		err = t.drain(ctx, machine, opts.Drain)
+	}
+
+	after := machine.GetStatus()
+	result := t.result(opts, time.Since(start))
+	result.TokenPasses = after.TokensProcessed - before.TokensProcessed
+	result.Transmissions = after.MessagesSent - before.MessagesSent
+	return result, err
+}
+
+// messageContent cria o conteúdo da n-ésima mensagem, completado até o tamanho pedido
+func messageContent(n, size int) string {
+	content := fmt.Sprintf("bench-%d-", n)
+	if len(content) < size {
+		content += strings.Repeat("x", size-len(content))
+	}
+	return content
+}
+
+// tracker acompanha as mensagens do benchmark pelos eventos da máquina
+type tracker struct {
+	mutex          sync.Mutex
+	queued         map[string]time.Time // Mensagens aceitas aguardando confirmação (destino e conteúdo)
+	latency        *metrics.Summary
+	histogram      *metrics.Histogram
+	rotation       *metrics.Summary
+	lastToken      time.Time
+	offered        int
+	rejected       int
+	delivered      int
+	naks           int
+	busy           int
+	notExists      int
+	perDestination map[string]DestinationResult
+}
+
+// newTracker cria o acompanhamento de um benchmark
+func newTracker() *tracker {
+	return &tracker{
+		queued:         make(map[string]time.Time),
+		latency:        metrics.NewSummary(1 << 16),
+		histogram:      metrics.NewHistogram(metrics.LatencyBuckets),
+		rotation:       metrics.NewSummary(1 << 16),
+		perDestination: make(map[string]DestinationResult),
+	}
+}
+
+// trackKey identifica uma mensagem do benchmark
+func trackKey(destination, content string) string {
+	return destination + "\x00" + content
+}
+
+// add registra uma mensagem gerada, antes de enfileirá-la (a confirmação pode chegar logo em seguida)
+func (t *tracker) add(destination, content string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.queued[trackKey(destination, content)] = time.Now()
+	t.offered++
+	d := t.perDestination[destination]
+	d.Offered++
+	t.perDestination[destination] = d
+}
+
+// reject desfaz o registro de uma mensagem recusada pela fila
+func (t *tracker) reject(destination, content string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.queued, trackKey(destination, content))
+	t.rejected++
+}
+
+// handle trata os eventos da máquina
+// Chamada no laço de eventos da máquina: apenas atualiza os contadores
+func (t *tracker) handle(event network.Event) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if event.Type == network.EventTokenReceived {
+		now := time.Now()
+		if !t.lastToken.IsZero() {
+			t.rotation.Observe(now.Sub(t.lastToken).Seconds())
+		}
+		t.lastToken = now
+		return
+	}
+
+	key := trackKey(event.Destination, event.Message)
+	queued, ok := t.queued[key]
+	if !ok {
+		return
+	}
+	switch event.Type {
+	case network.EventACK:
+		delete(t.queued, key)
+		latency := time.Since(queued).Seconds()
+		t.latency.Observe(latency)
+		t.histogram.Observe(latency)
+		t.delivered++
+		d := t.perDestination[event.Destination]
+		d.Delivered++
+		t.perDestination[event.Destination] = d
+	case network.EventNAK:
+		t.naks++
+	case network.EventBusy:
+		t.busy++
+	case network.EventNotExists:
+		t.notExists++
+	}
+}
+
+// pending retorna o número de mensagens aceitas ainda não confirmadas
+func (t *tracker) pending() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.queued)
+}
+
+// drain aguarda as confirmações até o tempo máximo, ou até nenhuma mensagem do benchmark restar na fila
+// (mensagens guardadas por uma caixa postal ou para destinos ausentes saem da fila sem ACK)
+func (t *tracker) drain(ctx context.Context, machine *network.Machine, limit time.Duration) error {
+	deadline := time.Now().Add(limit)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for t.pending() > 0 && time.Now().Before(deadline) && t.inQueue(machine) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// inQueue verifica se alguma mensagem do benchmark ainda está na fila da máquina
+func (t *tracker) inQueue(machine *network.Machine) bool {
+	queue := machine.GetMessageQueue()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, msg := range queue {
+		if _, ok := t.queued[trackKey(msg.Destination, msg.Content)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// result monta o resultado com os contadores acumulados
+func (t *tracker) result(opts Options, elapsed time.Duration) *Result {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	perDestination := make(map[string]DestinationResult, len(t.perDestination))
+	for destination, d := range t.perDestination {
+		perDestination[destination] = d
+	}
+	return &Result{
+		Options:        opts,
+		Elapsed:        elapsed,
+		Offered:        t.offered,
+		Rejected:       t.rejected,
+		Delivered:      t.delivered,
+		Pending:        len(t.queued),
+		NAKs:           t.naks,
+		Busy:           t.busy,
+		NotExists:      t.notExists,
+		Throughput:     float64(t.delivered) / elapsed.Seconds(),
+		Latency:        t.latency.Snapshot(),
+		Histogram:      t.histogram.Snapshot(),
+		TokenRotation:  t.rotation.Snapshot(),
+		PerDestination: perDestination,
+	}
+}
@@ -0,0 +1,62 @@
+package bench
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Generator sorteia os intervalos e os destinos das mensagens de um padrão de tráfego
+// Não depende da máquina nem do relógio, e pode ser usado com um relógio virtual
+type Generator struct {
+	opts   Options
+	rng    *rand.Rand
+	others []string // Destinos sorteados quando a mensagem não vai para o hotspot
+	count  int      // Mensagens geradas até agora
+}
+
+// NewGenerator cria um gerador para as opções informadas
+func NewGenerator(opts Options, rng *rand.Rand) *Generator {
+	g := &Generator{opts: opts, rng: rng}
+	for _, destination := range opts.Destinations {
+		if destination != opts.Hotspot {
+			g.others = append(g.others, destination)
+		}
+	}
+	return g
+}
+
+// Next retorna o intervalo desde a mensagem anterior e o destino da próxima mensagem
+// A primeira mensagem sai no início (intervalo zero)
+func (g *Generator) Next() (time.Duration, string) {
+	interval := g.interval()
+	g.count++
+	return interval, g.destination()
+}
+
+// interval sorteia o intervalo até a próxima mensagem, conforme o padrão
+func (g *Generator) interval() time.Duration {
+	if g.count == 0 {
+		return 0
+	}
+	mean := float64(time.Second) / g.opts.Rate
+	switch g.opts.Pattern {
+	case PatternPoisson:
+		return time.Duration(g.rng.ExpFloat64() * mean)
+	case PatternBurst:
+		// As mensagens de uma rajada saem juntas; as rajadas são espaçadas para manter a taxa média
+		if g.count%g.opts.BurstSize != 0 {
+			return 0
+		}
+		return time.Duration(mean * float64(g.opts.BurstSize))
+	default:
+		return time.Duration(mean)
+	}
+}
+
+// destination sorteia o destino: o hotspot com a fração configurada, os demais uniformemente
+func (g *Generator) destination() string {
+	if g.opts.Hotspot != "" && (len(g.others) == 0 || g.rng.Float64() < g.opts.HotspotShare) {
+		return g.opts.Hotspot
+	}
+	return g.others[g.rng.Intn(len(g.others))]
+}
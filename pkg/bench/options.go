@@ -0,0 +1,156 @@
+package bench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Padrões de tráfego
+const (
+	PatternConstant = "constante" // Intervalo fixo entre mensagens (1/taxa)
+	PatternPoisson  = "poisson"   // Chegadas de Poisson: intervalos exponenciais com média 1/taxa
+	PatternBurst    = "rajada"    // Rajadas de várias mensagens seguidas, espaçadas para manter a taxa média
+)
+
+// Valores padrão das opções
+const (
+	DefaultRate         = 1.0
+	DefaultDuration     = 30 * time.Second
+	DefaultBurstSize    = 5
+	DefaultHotspotShare = 0.8
+	DefaultSize         = 16
+	DefaultDrain        = 30 * time.Second
+)
+
+// Options define o tráfego gerado pelo benchmark
+type Options struct {
+	Pattern      string        // constante, poisson ou rajada
+	Rate         float64       // Mensagens por segundo, em média
+	Duration     time.Duration // Tempo de geração de mensagens
+	BurstSize    int           // Mensagens por rajada (padrão rajada)
+	Destinations []string      // Destinos sorteados (uniformemente, exceto o hotspot)
+	Hotspot      string        // Destino que recebe HotspotShare das mensagens (vazio = todos uniformes)
+	HotspotShare float64       // Fração das mensagens enviadas ao hotspot (0 a 1)
+	Size         int           // Tamanho do conteúdo de cada mensagem, em bytes
+	Drain        time.Duration // Tempo máximo de espera pelas confirmações após a geração
+	Seed         int64         // Semente do sorteio (0 = a partir do horário)
+}
+
+// DefaultOptions retorna as opções padrão (destinos ainda não definidos)
+func DefaultOptions() Options {
+	return Options{
+		Pattern:      PatternConstant,
+		Rate:         DefaultRate,
+		Duration:     DefaultDuration,
+		BurstSize:    DefaultBurstSize,
+		HotspotShare: DefaultHotspotShare,
+		Size:         DefaultSize,
+		Drain:        DefaultDrain,
+	}
+}
+
+// ParseOptions interpreta opções no formato chave=valor, a partir das opções padrão
+// Chaves: padrao, taxa, duracao, rajada, destinos, hotspot (nome[:fração]), tamanho, drenagem, semente
+// Tempos aceitam segundos (ex: 30) ou o formato do Go (ex: 500ms, 2m)
+func ParseOptions(args []string) (Options, error) {
+	opts := DefaultOptions()
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return opts, fmt.Errorf("opção inválida (esperado chave=valor): %s", arg)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "padrao":
+			opts.Pattern = strings.ToLower(value)
+		case "taxa":
+			opts.Rate, err = strconv.ParseFloat(value, 64)
+		case "duracao":
+			opts.Duration, err = parseDuration(value)
+		case "rajada":
+			opts.BurstSize, err = strconv.Atoi(value)
+		case "destinos":
+			opts.Destinations = nil
+			for _, destination := range strings.Split(value, ",") {
+				if destination = strings.TrimSpace(destination); destination != "" {
+					opts.Destinations = append(opts.Destinations, destination)
+				}
+			}
+		case "hotspot":
+			name, share, hasShare := strings.Cut(value, ":")
+			opts.Hotspot = name
+			if hasShare {
+				opts.HotspotShare, err = strconv.ParseFloat(share, 64)
+			}
+		case "tamanho":
+			opts.Size, err = strconv.Atoi(value)
+		case "drenagem":
+			opts.Drain, err = parseDuration(value)
+		case "semente":
+			opts.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return opts, fmt.Errorf("opção desconhecida: %s", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("valor inválido para %s: %s", key, value)
+		}
+	}
+	return opts, opts.validate(false)
+}
+
+// Validate verifica as opções, inclusive a lista de destinos
+func (o Options) Validate() error {
+	return o.validate(true)
+}
+
+// validate verifica as opções; a lista de destinos pode ficar para depois (ex: descoberta pelo censo)
+func (o Options) validate(requireDestinations bool) error {
+	switch o.Pattern {
+	case PatternConstant, PatternPoisson, PatternBurst:
+	default:
+		return fmt.Errorf("padrão de tráfego desconhecido: %s (use %s, %s ou %s)",
+			o.Pattern, PatternConstant, PatternPoisson, PatternBurst)
+	}
+	switch {
+	case o.Rate <= 0:
+		return fmt.Errorf("a taxa deve ser positiva: %v", o.Rate)
+	case o.Duration <= 0:
+		return fmt.Errorf("a duração deve ser positiva: %v", o.Duration)
+	case o.BurstSize < 1:
+		return fmt.Errorf("o tamanho da rajada deve ser positivo: %d", o.BurstSize)
+	case o.HotspotShare <= 0 || o.HotspotShare > 1:
+		return fmt.Errorf("a fração do hotspot deve estar entre 0 e 1: %v", o.HotspotShare)
+	case o.Size < 1:
+		return fmt.Errorf("o tamanho da mensagem deve ser positivo: %d", o.Size)
+	case o.Drain < 0:
+		return fmt.Errorf("o tempo de drenagem não pode ser negativo: %v", o.Drain)
+	case requireDestinations && len(o.Destinations) == 0 && o.Hotspot == "":
+		return fmt.Errorf("nenhum destino informado")
+	}
+	return nil
+}
+
+// String descreve o tráfego configurado em uma linha
+func (o Options) String() string {
+	text := fmt.Sprintf("%s, %g msg/s por %v", o.Pattern, o.Rate, o.Duration)
+	if o.Pattern == PatternBurst {
+		text += fmt.Sprintf(", rajadas de %d", o.BurstSize)
+	}
+	if o.Hotspot != "" {
+		text += fmt.Sprintf(", hotspot %s (%.0f%%)", o.Hotspot, 100*o.HotspotShare)
+	} else {
+		text += ", destinos uniformes"
+	}
+	return text + fmt.Sprintf(" entre %s, %d bytes", strings.Join(o.Destinations, ", "), o.Size)
+}
+
+// parseDuration interpreta um tempo em segundos (ex: 30, 0.5) ou no formato do Go (ex: 500ms)
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
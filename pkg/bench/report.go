@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"ring-network/pkg/metrics"
+)
+
+// WriteReport escreve o relatório do benchmark em texto
+func (r *Result) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "=== Benchmark: %s ===\n", r.Options)
+	fmt.Fprintf(w, "Mensagens: %d geradas (%.2f msg/s), %d aceitas, %d recusadas (fila cheia)\n",
+		r.Offered, float64(r.Offered)/r.Options.Duration.Seconds(), r.Offered-r.Rejected, r.Rejected)
+	fmt.Fprintf(w, "Entregues: %d (ACK), pendentes ao final: %d | NAK %d, BUSY %d, destino ausente %d\n",
+		r.Delivered, r.Pending, r.NAKs, r.Busy, r.NotExists)
+	fmt.Fprintf(w, "Vazão: %.3f msg/s em %v\n", r.Throughput, r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Latência (enfileiramento → ACK): %s\n", formatSeconds(r.Latency))
+	if r.Latency.Samples > 0 {
+		writeHistogram(w, r.Histogram)
+	}
+
+	fmt.Fprintf(w, "Volta do token: %s\n", formatSeconds(r.TokenRotation))
+	fmt.Fprintf(w, "Utilização: %d de %d passagens do token levaram um quadro de dados (%.1f%%)\n",
+		r.Transmissions, r.TokenPasses, 100*r.Utilization())
+	if capacity := r.Capacity(); capacity > 0 {
+		fmt.Fprintf(w, "Capacidade desta estação: %.3f msg/s (uma mensagem por volta); vazão/capacidade %.1f%%\n",
+			capacity, 100*r.Throughput/capacity)
+	}
+
+	destinations := make([]string, 0, len(r.PerDestination))
+	for destination := range r.PerDestination {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+	items := make([]string, len(destinations))
+	for i, destination := range destinations {
+		d := r.PerDestination[destination]
+		items[i] = fmt.Sprintf("%s %d/%d", destination, d.Delivered, d.Offered)
+	}
+	fmt.Fprintf(w, "Por destino (entregues/geradas): %s\n", strings.Join(items, ", "))
+}
+
+// writeHistogram escreve a distribuição da latência, uma faixa por linha
+// Faixas vazias no início e no fim são omitidas
+func writeHistogram(w io.Writer, h metrics.HistogramSnapshot) {
+	// Converte as contagens cumulativas em contagens por faixa, com a faixa +Inf no final
+	counts := make([]uint64, len(h.Bounds)+1)
+	var previous uint64
+	for i := range h.Bounds {
+		counts[i] = h.Counts[i] - previous
+		previous = h.Counts[i]
+	}
+	counts[len(h.Bounds)] = h.Count - previous
+
+	first, last := -1, -1
+	var largest uint64
+	for i, count := range counts {
+		if count > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			largest = max(largest, count)
+		}
+	}
+	if first < 0 {
+		return
+	}
+
+	for i := first; i <= last; i++ {
+		label := "> " + formatBound(h.Bounds[len(h.Bounds)-1])
+		if i < len(h.Bounds) {
+			label = "≤ " + formatBound(h.Bounds[i])
+		}
+		bar := strings.Repeat("█", int(40*counts[i]/largest))
+		fmt.Fprintf(w, "  %8s %5d %s\n", label, counts[i], bar)
+	}
+}
+
+// formatBound formata o limite de uma faixa do histograma (em segundos)
+func formatBound(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).String()
+}
+
+// formatSeconds resume uma série de tempos em segundos: mín/média/p50/p95/máx
+func formatSeconds(s metrics.SummarySnapshot) string {
+	if s.Samples == 0 {
+		return "sem amostras"
+	}
+	d := func(seconds float64) time.Duration {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond)
+	}
+	return fmt.Sprintf("mín %v, média %v, p50 %v, p95 %v, máx %v (%d amostra(s))",
+		d(s.Min), d(s.Avg), d(s.P50), d(s.P95), d(s.Max), s.Samples)
+}
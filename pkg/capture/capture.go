@@ -0,0 +1,172 @@
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formato de cada pacote da captura (tipo de enlace LinkType):
+//
+//	0      1 byte   versão do cabeçalho (HeaderVersion)
+//	1      1 byte   direção: 0 = recebido, 1 = enviado
+//	2      2 bytes  tamanho N do endereço do par (big-endian)
+//	4      N bytes  endereço do par, em texto (ex: "127.0.0.1:6001")
+//	4+N    ...      quadro, exatamente como transmitido (ex: "2000|lc=3;Alice:Bob:ACK:123:oi")
+//
+// A direção também é gravada na opção epb_flags de cada bloco, que as
+// ferramentas de análise exibem sem precisar do dissector.
+
+// LinkType é o tipo de enlace das capturas: LINKTYPE_USER0, reservado para uso privado
+// No Wireshark, o dissector em tools/wireshark/ring_network.lua é associado a ele
+const LinkType = 147
+
+// HeaderVersion é a versão do cabeçalho gravado antes de cada quadro
+const HeaderVersion = 1
+
+// Direction indica se o quadro foi recebido ou enviado pela estação
+type Direction uint8
+
+// Direções de um quadro
+const (
+	Received Direction = 0
+	Sent     Direction = 1
+)
+
+// String retorna o nome da direção
+func (d Direction) String() string {
+	if d == Sent {
+		return "enviado"
+	}
+	return "recebido"
+}
+
+// MarshalText grava a direção pelo nome, nos arquivos JSONL
+func (d Direction) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText lê a direção pelo nome
+func (d *Direction) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "enviado":
+		*d = Sent
+	case "recebido":
+		*d = Received
+	default:
+		return fmt.Errorf("direção desconhecida: %s", text)
+	}
+	return nil
+}
+
+// Packet é um quadro capturado por uma estação
+type Packet struct {
+	Time      time.Time `json:"horario"` // Horário segundo o relógio do sistema da estação
+	Station   string    `json:"maquina"` // Estação que capturou o quadro
+	Direction Direction `json:"direcao"` // Enviado ou recebido pela estação
+	Peer      string    `json:"par"`     // Endereço de quem enviou (recebido) ou do destino (enviado)
+	Frame     string    `json:"quadro"`  // Quadro exatamente como transmitido
+}
+
+// Recorder grava os quadros enviados e recebidos por uma estação
+// Implementado por Writer (pcapng) e JSONWriter (JSONL)
+type Recorder interface {
+	WritePacket(t time.Time, direction Direction, peer string, frame []byte) error
+	Close() error
+}
+
+// Open cria o arquivo de captura no formato indicado pela extensão:
+// .jsonl ou .json grava um quadro JSON por linha; qualquer outra grava pcapng
+func Open(path, station string) (Recorder, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".json":
+		w, err := CreateJSON(path, station)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	default:
+		w, err := Create(path, station)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+}
+
+// Writer grava os quadros de uma estação em um arquivo pcapng
+// Pode ser usado por várias goroutines (recepção e envio)
+type Writer struct {
+	w      io.Writer  // Destino dos blocos
+	closer io.Closer  // Arquivo aberto por Create (nil com NewWriter)
+	mutex  sync.Mutex // Mantém os blocos inteiros e em ordem
+}
+
+// Create cria o arquivo de captura, substituindo um existente
+func Create(path, station string) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de captura: %v", err)
+	}
+
+	w, err := NewWriter(file, station)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.closer = file
+	return w, nil
+}
+
+// NewWriter inicia uma captura pcapng no destino informado
+// Grava o cabeçalho da seção e a descrição da interface, identificada pelo nome da estação
+func NewWriter(w io.Writer, station string) (*Writer, error) {
+	header := sectionHeaderBlock("ring-network")
+	header = append(header, interfaceDescriptionBlock(station)...)
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("erro ao gravar cabeçalho da captura: %v", err)
+	}
+	return &Writer{w: w}, nil
+}
+
+// WritePacket grava um quadro enviado ou recebido, com o horário e o endereço do par
+func (w *Writer) WritePacket(t time.Time, direction Direction, peer string, frame []byte) error {
+	if len(peer) > 0xFFFF {
+		return fmt.Errorf("endereço do par muito longo: %d bytes", len(peer))
+	}
+
+	data := make([]byte, 4, 4+len(peer)+len(frame))
+	data[0] = HeaderVersion
+	data[1] = byte(direction)
+	binary.BigEndian.PutUint16(data[2:], uint16(len(peer)))
+	data = append(data, peer...)
+	data = append(data, frame...)
+
+	block := enhancedPacketBlock(t, direction, data)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.w.Write(block); err != nil {
+		return fmt.Errorf("erro ao gravar pacote na captura: %v", err)
+	}
+	return nil
+}
+
+// Close fecha o arquivo de captura criado por Create
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closer == nil {
+		return nil
+	}
+	err := w.closer.Close()
+	w.closer = nil
+	return err
+}
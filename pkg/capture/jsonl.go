@@ -0,0 +1,64 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONWriter grava os quadros de uma estação em JSONL, um Packet por linha
+// Alternativa legível ao pcapng, fácil de filtrar com ferramentas de texto
+// Pode ser usado por várias goroutines (recepção e envio)
+type JSONWriter struct {
+	encoder *json.Encoder // Codifica cada quadro em uma linha
+	station string        // Estação que captura os quadros
+	closer  io.Closer     // Arquivo aberto por CreateJSON (nil com NewJSONWriter)
+	mutex   sync.Mutex    // Mantém as linhas inteiras e em ordem
+}
+
+// CreateJSON cria o arquivo JSONL de captura, substituindo um existente
+func CreateJSON(path, station string) (*JSONWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de captura: %v", err)
+	}
+
+	w := NewJSONWriter(file, station)
+	w.closer = file
+	return w, nil
+}
+
+// NewJSONWriter inicia uma captura JSONL no destino informado
+func NewJSONWriter(w io.Writer, station string) *JSONWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &JSONWriter{encoder: encoder, station: station}
+}
+
+// WritePacket grava um quadro enviado ou recebido, com o horário e o endereço do par
+func (w *JSONWriter) WritePacket(t time.Time, direction Direction, peer string, frame []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	packet := Packet{Time: t, Station: w.station, Direction: direction, Peer: peer, Frame: string(frame)}
+	if err := w.encoder.Encode(packet); err != nil {
+		return fmt.Errorf("erro ao gravar pacote na captura: %v", err)
+	}
+	return nil
+}
+
+// Close fecha o arquivo de captura criado por CreateJSON
+func (w *JSONWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closer == nil {
+		return nil
+	}
+	err := w.closer.Close()
+	w.closer = nil
+	return err
+}
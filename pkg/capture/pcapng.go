@@ -0,0 +1,111 @@
+package capture
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Blocos do formato pcapng (especificação do IETF: draft-ietf-opsawg-pcapng)
+// Os blocos são gravados em little-endian; o leitor descobre a ordem pelo byteOrderMagic
+const (
+	blockSectionHeader        = 0x0A0D0D0A
+	blockInterfaceDescription = 0x00000001
+	blockEnhancedPacket       = 0x00000006
+
+	byteOrderMagic = 0x1A2B3C4D
+
+	optEndOfOpt        = 0
+	optShbUserAppl     = 4
+	optIfName          = 2
+	optIfDescription   = 3
+	optIfTsResol       = 9
+	optEpbFlags        = 2
+	epbFlagInbound     = 1 // Bits 0-1 de epb_flags: 01 = entrada
+	epbFlagOutbound    = 2 // Bits 0-1 de epb_flags: 10 = saída
+	timestampPrecision = 9 // if_tsresol: horários em nanossegundos (10^-9 s)
+)
+
+// le é a ordem de bytes dos blocos gravados
+var le = binary.LittleEndian
+
+// sectionHeaderBlock monta o bloco que inicia a seção (o arquivo)
+func sectionHeaderBlock(application string) []byte {
+	body := make([]byte, 16)
+	le.PutUint32(body[0:], byteOrderMagic)
+	le.PutUint16(body[4:], 1) // Versão 1.0
+	le.PutUint16(body[6:], 0)
+	le.PutUint64(body[8:], 0xFFFFFFFFFFFFFFFF) // Tamanho da seção não informado
+
+	body = appendOption(body, optShbUserAppl, []byte(application))
+	body = appendEndOfOptions(body)
+	return block(blockSectionHeader, body)
+}
+
+// interfaceDescriptionBlock monta o bloco que descreve a interface de captura (a estação)
+func interfaceDescriptionBlock(station string) []byte {
+	body := make([]byte, 8)
+	le.PutUint16(body[0:], LinkType)
+	le.PutUint32(body[4:], 0) // Sem limite de tamanho dos pacotes
+
+	body = appendOption(body, optIfName, []byte(station))
+	body = appendOption(body, optIfDescription, []byte("Estação "+station+" da rede em anel"))
+	body = appendOption(body, optIfTsResol, []byte{timestampPrecision})
+	body = appendEndOfOptions(body)
+	return block(blockInterfaceDescription, body)
+}
+
+// enhancedPacketBlock monta o bloco de um pacote capturado na interface 0
+func enhancedPacketBlock(t time.Time, direction Direction, data []byte) []byte {
+	timestamp := uint64(t.UnixNano())
+
+	body := make([]byte, 20, 20+len(data)+24)
+	le.PutUint32(body[0:], 0) // Interface
+	le.PutUint32(body[4:], uint32(timestamp>>32))
+	le.PutUint32(body[8:], uint32(timestamp))
+	le.PutUint32(body[12:], uint32(len(data))) // Tamanho capturado
+	le.PutUint32(body[16:], uint32(len(data))) // Tamanho original
+	body = append(body, data...)
+	body = appendPadding(body)
+
+	flags := make([]byte, 4)
+	if direction == Sent {
+		le.PutUint32(flags, epbFlagOutbound)
+	} else {
+		le.PutUint32(flags, epbFlagInbound)
+	}
+	body = appendOption(body, optEpbFlags, flags)
+	body = appendEndOfOptions(body)
+	return block(blockEnhancedPacket, body)
+}
+
+// block envolve o corpo com o tipo e o tamanho total (repetido ao final)
+func block(blockType uint32, body []byte) []byte {
+	total := uint32(12 + len(body))
+	b := make([]byte, 8, total)
+	le.PutUint32(b[0:], blockType)
+	le.PutUint32(b[4:], total)
+	b = append(b, body...)
+	return le.AppendUint32(b, total)
+}
+
+// appendOption acrescenta uma opção (código, tamanho, valor alinhado a 32 bits)
+func appendOption(b []byte, code uint16, value []byte) []byte {
+	b = le.AppendUint16(b, code)
+	b = le.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return appendPadding(b)
+}
+
+// appendEndOfOptions encerra a lista de opções
+func appendEndOfOptions(b []byte) []byte {
+	b = le.AppendUint16(b, optEndOfOpt)
+	return le.AppendUint16(b, 0)
+}
+
+// appendPadding completa com zeros até um múltiplo de 4 bytes
+func appendPadding(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
@@ -0,0 +1,233 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ReadFile lê um arquivo de captura, em pcapng ou JSONL (o formato é detectado pelo conteúdo)
+func ReadFile(path string) ([]Packet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir captura: %v", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	start, err := reader.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("erro ao ler captura: %v", err)
+	}
+	if len(start) == 4 && binary.LittleEndian.Uint32(start) == blockSectionHeader {
+		return ReadPcapng(reader)
+	}
+	return ReadJSON(reader)
+}
+
+// ReadJSON lê uma captura JSONL, um Packet por linha; linhas vazias são ignoradas
+func ReadJSON(r io.Reader) ([]Packet, error) {
+	var packets []Packet
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var packet Packet
+		if err := json.Unmarshal(text, &packet); err != nil {
+			return nil, fmt.Errorf("linha %d: %v", line, err)
+		}
+		packets = append(packets, packet)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler captura: %v", err)
+	}
+	return packets, nil
+}
+
+// pcapngInterface guarda o que é preciso de uma interface para decodificar seus pacotes
+type pcapngInterface struct {
+	linkType   uint16
+	name       string
+	resolution float64 // Segundos por unidade de horário
+}
+
+// ReadPcapng lê uma captura pcapng
+// Apenas os pacotes de interfaces com o tipo de enlace LinkType são retornados;
+// blocos de outros tipos são ignorados
+func ReadPcapng(r io.Reader) ([]Packet, error) {
+	var (
+		packets    []Packet
+		order      binary.ByteOrder = binary.LittleEndian
+		interfaces []pcapngInterface
+	)
+
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(r, head[:]); err == io.EOF {
+			return packets, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("bloco incompleto: %v", err)
+		}
+
+		blockType := order.Uint32(head[0:])
+		if blockType == blockSectionHeader {
+			// Nova seção: a ordem de bytes é definida pelo magic, logo após o tamanho
+			var magic [4]byte
+			if _, err := io.ReadFull(r, magic[:]); err != nil {
+				return nil, fmt.Errorf("cabeçalho de seção incompleto: %v", err)
+			}
+			switch {
+			case binary.LittleEndian.Uint32(magic[:]) == byteOrderMagic:
+				order = binary.LittleEndian
+			case binary.BigEndian.Uint32(magic[:]) == byteOrderMagic:
+				order = binary.BigEndian
+			default:
+				return nil, fmt.Errorf("arquivo não é pcapng")
+			}
+			interfaces = nil
+
+			total := order.Uint32(head[4:])
+			if total < 28 || total%4 != 0 {
+				return nil, fmt.Errorf("tamanho de bloco inválido: %d", total)
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(total)-12); err != nil {
+				return nil, fmt.Errorf("cabeçalho de seção incompleto: %v", err)
+			}
+			continue
+		}
+
+		total := order.Uint32(head[4:])
+		if total < 12 || total%4 != 0 {
+			return nil, fmt.Errorf("tamanho de bloco inválido: %d", total)
+		}
+		body := make([]byte, total-8)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, fmt.Errorf("bloco incompleto: %v", err)
+		}
+		body = body[:len(body)-4] // Tamanho repetido ao final
+
+		switch blockType {
+		case blockInterfaceDescription:
+			iface, err := parseInterface(order, body)
+			if err != nil {
+				return nil, err
+			}
+			interfaces = append(interfaces, iface)
+
+		case blockEnhancedPacket:
+			packet, ok, err := parseEnhancedPacket(order, body, interfaces)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				packets = append(packets, packet)
+			}
+		}
+	}
+}
+
+// parseInterface decodifica um bloco de descrição de interface
+func parseInterface(order binary.ByteOrder, body []byte) (pcapngInterface, error) {
+	if len(body) < 8 {
+		return pcapngInterface{}, fmt.Errorf("descrição de interface incompleta")
+	}
+	iface := pcapngInterface{linkType: order.Uint16(body[0:]), resolution: 1e-6}
+
+	err := parseOptions(order, body[8:], func(code uint16, value []byte) {
+		switch code {
+		case optIfName:
+			iface.name = string(value)
+		case optIfTsResol:
+			if len(value) < 1 {
+				return
+			}
+			// Bit mais alto: potência de 2; caso contrário, potência de 10
+			if value[0]&0x80 != 0 {
+				iface.resolution = math.Pow(2, -float64(value[0]&0x7F))
+			} else {
+				iface.resolution = math.Pow(10, -float64(value[0]))
+			}
+		}
+	})
+	return iface, err
+}
+
+// parseEnhancedPacket decodifica um pacote; ok é false se a interface não for da rede em anel
+func parseEnhancedPacket(order binary.ByteOrder, body []byte, interfaces []pcapngInterface) (Packet, bool, error) {
+	if len(body) < 20 {
+		return Packet{}, false, fmt.Errorf("pacote incompleto")
+	}
+	id := order.Uint32(body[0:])
+	if int(id) >= len(interfaces) {
+		return Packet{}, false, fmt.Errorf("pacote de interface desconhecida: %d", id)
+	}
+	iface := interfaces[id]
+	if iface.linkType != LinkType {
+		return Packet{}, false, nil
+	}
+
+	units := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+	captured := order.Uint32(body[12:])
+	if int(captured) > len(body)-20 {
+		return Packet{}, false, fmt.Errorf("pacote truncado")
+	}
+	data := body[20 : 20+captured]
+
+	if len(data) < 4 || data[0] != HeaderVersion {
+		return Packet{}, false, fmt.Errorf("cabeçalho de pacote inválido")
+	}
+	peerLen := int(binary.BigEndian.Uint16(data[2:]))
+	if 4+peerLen > len(data) {
+		return Packet{}, false, fmt.Errorf("cabeçalho de pacote inválido")
+	}
+
+	return Packet{
+		Time:      unitsToTime(units, iface.resolution),
+		Station:   iface.name,
+		Direction: Direction(data[1]),
+		Peer:      string(data[4 : 4+peerLen]),
+		Frame:     string(data[4+peerLen:]),
+	}, true, nil
+}
+
+// unitsToTime converte o horário de um pacote, na resolução da interface, para time.Time
+func unitsToTime(units uint64, resolution float64) time.Time {
+	if resolution == 1e-9 {
+		return time.Unix(0, int64(units))
+	}
+	perSecond := uint64(math.Round(1 / resolution))
+	if perSecond == 0 {
+		return time.Unix(int64(float64(units)*resolution), 0)
+	}
+	seconds := units / perSecond
+	fraction := float64(units%perSecond) * resolution
+	return time.Unix(int64(seconds), int64(fraction*1e9))
+}
+
+// parseOptions percorre as opções de um bloco até opt_endofopt
+func parseOptions(order binary.ByteOrder, data []byte, visit func(code uint16, value []byte)) error {
+	for len(data) >= 4 {
+		code := order.Uint16(data[0:])
+		length := int(order.Uint16(data[2:]))
+		if code == optEndOfOpt {
+			return nil
+		}
+		padded := (length + 3) &^ 3
+		if 4+padded > len(data) {
+			return fmt.Errorf("opção de bloco incompleta")
+		}
+		visit(code, data[4:4+length])
+		data = data[4+padded:]
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package clock
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logical mantém o relógio de Lamport de uma estação e, opcionalmente, o relógio vetorial
+// O relógio avança a cada envio e, no recebimento, salta para além do valor recebido,
+// de modo que eventos causalmente relacionados ficam ordenados em todos os logs
+type Logical struct {
+	name    string         // Nome da estação dona do relógio
+	lamport int            // Relógio de Lamport
+	vector  map[string]int // Relógio vetorial (nil se desabilitado)
+	mutex   sync.Mutex     // Mutex para acesso concorrente
+}
+
+// NewLogical cria o relógio lógico de uma estação
+// withVector habilita o relógio vetorial, que cresce com o número de estações do anel
+func NewLogical(name string, withVector bool) *Logical {
+	l := &Logical{name: name}
+	if withVector {
+		l.vector = map[string]int{name: 0}
+	}
+	return l
+}
+
+// Send registra um evento de envio e retorna os valores a transmitir no quadro
+// O relógio vetorial é retornado vazio quando desabilitado
+func (l *Logical) Send() (int, string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lamport++
+	if l.vector == nil {
+		return l.lamport, ""
+	}
+	l.vector[l.name]++
+	return l.lamport, FormatVector(l.vector)
+}
+
+// Receive registra um evento de recebimento com os valores transmitidos no quadro
+// Quadros sem relógio (ex: de estações antigas) contam apenas como evento local
+func (l *Logical) Receive(lamport int, vector map[string]int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if lamport > l.lamport {
+		l.lamport = lamport
+	}
+	l.lamport++
+
+	if l.vector == nil {
+		return
+	}
+	for name, value := range vector {
+		if value > l.vector[name] {
+			l.vector[name] = value
+		}
+	}
+	l.vector[l.name]++
+}
+
+// Lamport retorna o valor atual do relógio de Lamport
+func (l *Logical) Lamport() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.lamport
+}
+
+// Vector retorna uma cópia do relógio vetorial (nil se desabilitado)
+func (l *Logical) Vector() map[string]int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.vector == nil {
+		return nil
+	}
+	vector := make(map[string]int, len(l.vector))
+	for name, value := range l.vector {
+		vector[name] = value
+	}
+	return vector
+}
+
+// String retorna os relógios no formato usado nas linhas de log (ex: "lc=12 vc=Alice:4,Bob:3")
+func (l *Logical) String() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.vector == nil {
+		return fmt.Sprintf("lc=%d", l.lamport)
+	}
+	return fmt.Sprintf("lc=%d vc=%s", l.lamport, FormatVector(l.vector))
+}
+
+// FormatVector converte um relógio vetorial para o formato do cabeçalho: "Alice:4,Bob:3"
+// As estações são ordenadas pelo nome para que o mesmo vetor tenha sempre a mesma representação
+func FormatVector(vector map[string]int) string {
+	names := make([]string, 0, len(vector))
+	for name := range vector {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = fmt.Sprintf("%s:%d", name, vector[name])
+	}
+	return strings.Join(entries, ",")
+}
+
+// ParseVector analisa um relógio vetorial no formato do cabeçalho
+// Retorna nil para um valor vazio
+func ParseVector(value string) (map[string]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	vector := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		name, count, ok := strings.Cut(entry, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("entrada de relógio vetorial inválida: %s", entry)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("valor de relógio vetorial inválido: %v", err)
+		}
+		vector[name] = n
+	}
+	return vector, nil
+}
+
+// HappenedBefore indica se o evento com o vetor a precede causalmente o evento com o vetor b
+func HappenedBefore(a, b map[string]int) bool {
+	strictly := false
+	for name, value := range a {
+		if value > b[name] {
+			return false
+		}
+		if value < b[name] {
+			strictly = true
+		}
+	}
+	for name, value := range b {
+		if _, ok := a[name]; !ok && value > 0 {
+			strictly = true
+		}
+	}
+	return strictly
+}
@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Physical representa a noção de tempo da estação: o relógio do sistema mais uma correção
+// A correção é ajustada pela sincronização de relógios sem alterar o relógio do sistema
+type Physical struct {
+	source      func() time.Time // Relógio do sistema (ou o relógio virtual de uma simulação)
+	offset      time.Duration    // Correção aplicada ao relógio do sistema
+	adjustments int              // Número de correções recebidas da sincronização
+	mutex       sync.RWMutex     // Mutex para acesso concorrente
+}
+
+// NewPhysical cria o relógio da estação com uma correção inicial
+// Uma correção inicial diferente de zero simula um relógio dessincronizado
+func NewPhysical(offset time.Duration) *Physical {
+	return &Physical{source: time.Now, offset: offset}
+}
+
+// NewPhysicalFrom cria o relógio da estação sobre outra fonte de tempo (ex: o relógio virtual de uma simulação)
+func NewPhysicalFrom(source func() time.Time, offset time.Duration) *Physical {
+	return &Physical{source: source, offset: offset}
+}
+
+// Now retorna o horário atual segundo a estação
+func (p *Physical) Now() time.Time {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.source().Add(p.offset)
+}
+
+// Adjust soma uma correção ao relógio da estação
+func (p *Physical) Adjust(delta time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.offset += delta
+	p.adjustments++
+}
+
+// Offset retorna a diferença atual entre o relógio da estação e o relógio do sistema
+func (p *Physical) Offset() time.Duration {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.offset
+}
+
+// Adjustments retorna o número de correções aplicadas
+func (p *Physical) Adjustments() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.adjustments
+}
@@ -3,11 +3,12 @@ package config
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+
+	"ring-network/pkg/logging"
 )
 
 // Config armazena as configurações de uma máquina na rede em anel
@@ -18,14 +19,41 @@ type Config struct {
 	GeneratesToken  bool   // Indica se esta máquina gera o token inicial
 	ListenPort      int    // Porta em que a máquina escuta por conexões
 	LogFile         string // Caminho do arquivo de log
+
+	ReceiveBufferSize int      // Capacidade do buffer de recepção (mensagens ainda não lidas)
+	Groups            []string // Grupos multicast dos quais a máquina participa
+	Mailbox           bool     // Indica se a máquina atua como caixa postal
+	MailboxFile       string   // Arquivo onde a caixa postal guarda as mensagens
+	MaxLockTime       int      // Tempo máximo em segundos que a aplicação pode reter o token (Acquire)
+	PrevMachineAddr   string   // Endereço da máquina anterior (IP:porta), para o anel bidirecional
+	VectorClock       bool     // Indica se os quadros carregam o relógio vetorial além do relógio de Lamport
+	ClockSkew         int      // Desvio inicial em milissegundos do relógio da máquina (simula relógios dessincronizados)
+	SyncInterval      int      // Intervalo em segundos entre sincronizações de relógio (0 = apenas manual)
+	AdminAddr         string   // Endereço do servidor HTTP de administração (ex: ":8080"; vazio = desabilitado)
+	LogLevel          string   // Nível mínimo das linhas de log (debug, info, aviso, erro)
+	LogFormat         string   // Formato das linhas de log (texto ou json)
+	LogMirror         bool     // Indica se as linhas de log também são exibidas no terminal
+	LogAppend         bool     // Indica se o arquivo de log é preservado entre execuções (em vez de truncado)
+	LogMaxSize        int      // Tamanho em KB a partir do qual o arquivo de log é rotacionado (0 = sem limite)
+	LogMaxAge         int      // Idade em segundos a partir da qual o arquivo de log é rotacionado (0 = sem limite)
+	LogBackups        int      // Número de arquivos de log rotacionados mantidos (0 = todos)
+	CaptureFile       string   // Arquivo pcapng (ou .jsonl) onde os quadros enviados e recebidos são gravados (vazio = sem captura)
 }
 
+// Valores padrão das opções adicionais
+const (
+	DefaultReceiveBufferSize = 10     // Capacidade padrão do buffer de recepção
+	DefaultMaxLockTime       = 5      // Tempo máximo padrão de posse da seção crítica, em segundos
+	DefaultLogLevel          = "info" // Nível mínimo padrão das linhas de log
+)
+
 // LoadConfig carrega as configurações a partir de um arquivo
 // O arquivo deve conter pelo menos 4 linhas não comentadas:
 // 1. Endereço da próxima máquina (IP:porta)
 // 2. Nome desta máquina
 // 3. Tempo do token em segundos
 // 4. Flag indicando se gera token inicial (true/false)
+// Linhas adicionais opcionais seguem o formato chave=valor (ex: buffer_recepcao=5)
 func LoadConfig(filename string) (*Config, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -53,7 +81,12 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("arquivo de configuração incompleto. Esperado 4 linhas, encontrado %d", len(lines))
 	}
 
-	cfg := &Config{}
+	cfg := &Config{
+		ReceiveBufferSize: DefaultReceiveBufferSize,
+		MaxLockTime:       DefaultMaxLockTime,
+		LogLevel:          DefaultLogLevel,
+		LogFormat:         logging.FormatText,
+	}
 
 	// Endereço da próxima máquina
 	cfg.NextMachineAddr = lines[0]
@@ -75,9 +108,21 @@ func LoadConfig(filename string) (*Config, error) {
 	}
 	cfg.GeneratesToken = generatesToken
 
+	// Opções adicionais no formato chave=valor
+	for _, line := range lines[4:] {
+		if err := cfg.parseOption(line); err != nil {
+			return nil, err
+		}
+	}
+
 	// Define o arquivo de log baseado no nome da máquina
 	cfg.LogFile = fmt.Sprintf("%s_log.txt", strings.ToLower(cfg.MachineName))
 
+	// Define o arquivo da caixa postal, se não informado
+	if cfg.MailboxFile == "" {
+		cfg.MailboxFile = fmt.Sprintf("%s_caixa_postal.json", strings.ToLower(cfg.MachineName))
+	}
+
 	// Define a porta de escuta com base no nome da máquina ou no endereço da próxima
 	switch cfg.MachineName {
 	case "Alice":
@@ -103,6 +148,114 @@ func LoadConfig(filename string) (*Config, error) {
 	return cfg, nil
 }
 
+// parseOption interpreta uma linha opcional no formato chave=valor
+func (c *Config) parseOption(line string) error {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return fmt.Errorf("opção inválida (esperado chave=valor): %s", line)
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "buffer_recepcao":
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("tamanho do buffer de recepção inválido: %v", err)
+		}
+		c.ReceiveBufferSize = size
+	case "grupos":
+		for _, group := range strings.Split(value, ",") {
+			group = strings.TrimPrefix(strings.TrimSpace(group), "@")
+			if group != "" {
+				c.Groups = append(c.Groups, group)
+			}
+		}
+	case "caixa_postal":
+		mailbox, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("valor de caixa postal inválido: %v", err)
+		}
+		c.Mailbox = mailbox
+	case "caixa_postal_arquivo":
+		c.MailboxFile = value
+	case "maquina_anterior":
+		c.PrevMachineAddr = value
+	case "tempo_max_lock":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("tempo máximo de seção crítica inválido: %v", err)
+		}
+		c.MaxLockTime = seconds
+	case "relogio_vetorial":
+		vector, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("valor de relógio vetorial inválido: %v", err)
+		}
+		c.VectorClock = vector
+	case "desvio_relogio":
+		skew, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("desvio do relógio inválido: %v", err)
+		}
+		c.ClockSkew = skew
+	case "api_http":
+		c.AdminAddr = value
+	case "sincronizacao_relogio":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("intervalo de sincronização de relógio inválido: %v", err)
+		}
+		c.SyncInterval = seconds
+	case "captura":
+		c.CaptureFile = value
+	case "log_nivel":
+		if _, err := logging.ParseLevel(value); err != nil {
+			return err
+		}
+		c.LogLevel = value
+	case "log_formato":
+		if err := logging.ValidateFormat(value); err != nil {
+			return err
+		}
+		c.LogFormat = value
+	case "log_terminal":
+		mirror, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("valor de log no terminal inválido: %v", err)
+		}
+		c.LogMirror = mirror
+	case "log_anexar":
+		appendMode, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("valor de anexação do log inválido: %v", err)
+		}
+		c.LogAppend = appendMode
+	case "log_tamanho_max":
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("tamanho máximo do arquivo de log inválido: %v", err)
+		}
+		c.LogMaxSize = size
+	case "log_idade_max":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("idade máxima do arquivo de log inválida: %v", err)
+		}
+		c.LogMaxAge = seconds
+	case "log_backups":
+		backups, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("número de arquivos de log mantidos inválido: %v", err)
+		}
+		c.LogBackups = backups
+	default:
+		return fmt.Errorf("opção desconhecida: %s", key)
+	}
+
+	return nil
+}
+
 // Validate verifica se a configuração é válida
 func (c *Config) Validate() error {
 	if c.NextMachineAddr == "" {
@@ -121,35 +274,71 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("porta deve estar entre 1 e 65535")
 	}
 
+	if c.ReceiveBufferSize <= 0 {
+		return fmt.Errorf("buffer de recepção deve ser maior que zero")
+	}
+
+	if c.MaxLockTime <= 0 {
+		return fmt.Errorf("tempo máximo de seção crítica deve ser maior que zero")
+	}
+
+	if c.SyncInterval < 0 {
+		return fmt.Errorf("intervalo de sincronização de relógio não pode ser negativo")
+	}
+
+	if c.LogMaxSize < 0 || c.LogMaxAge < 0 || c.LogBackups < 0 {
+		return fmt.Errorf("limites de rotação do log não podem ser negativos")
+	}
+
+	// O nome identifica a estação no relógio vetorial transmitido no cabeçalho
+	if c.VectorClock && strings.ContainsAny(c.MachineName, ":,=;| ") {
+		return fmt.Errorf("nome de máquina inválido para o relógio vetorial: %s", c.MachineName)
+	}
+
+	for _, group := range c.Groups {
+		if err := ValidateGroupName(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateGroupName verifica se um nome de grupo pode ser usado como endereço
+// Os caracteres ":", ",", "=" e ";" são reservados pelo formato dos pacotes
+func ValidateGroupName(group string) error {
+	if group == "" {
+		return fmt.Errorf("nome do grupo não pode estar vazio")
+	}
+	if strings.ContainsAny(group, ":,=; ") {
+		return fmt.Errorf("nome de grupo inválido: %s", group)
+	}
 	return nil
 }
 
 // String retorna uma representação em string da configuração
 func (c *Config) String() string {
-	return fmt.Sprintf("Config{NextMachine: %s, Name: %s, TokenTime: %d, GeneratesToken: %t, ListenPort: %d, LogFile: %s}",
-		c.NextMachineAddr, c.MachineName, c.TokenTime, c.GeneratesToken, c.ListenPort, c.LogFile)
+	return fmt.Sprintf("Config{NextMachine: %s, Name: %s, TokenTime: %d, GeneratesToken: %t, ListenPort: %d, LogFile: %s, ReceiveBuffer: %d, Groups: %v, Mailbox: %t, VectorClock: %t}",
+		c.NextMachineAddr, c.MachineName, c.TokenTime, c.GeneratesToken, c.ListenPort, c.LogFile, c.ReceiveBufferSize, c.Groups, c.Mailbox, c.VectorClock)
 }
 
-// SetupLogger configura o sistema de log para gravar em arquivo
-func (c *Config) SetupLogger() error {
-	// Cria o diretório de logs se necessário
-	logDir := filepath.Dir(c.LogFile)
-	if logDir != "." && logDir != "" {
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return fmt.Errorf("erro ao criar diretório de logs: %v", err)
-		}
-	}
+// LogOptions retorna as opções de log descritas pela configuração
+// As linhas vão para o arquivo de log e, com log_terminal=true, também para a saída de erro
+func (c *Config) LogOptions() logging.Options {
+	// O nível já foi validado na leitura; um valor inválido atribuído diretamente vale como info
+	level, _ := logging.ParseLevel(c.LogLevel)
 
-	// Abre o arquivo de log (cria se não existir, trunca se existir)
-	logFile, err := os.OpenFile(c.LogFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return fmt.Errorf("erro ao abrir arquivo de log: %v", err)
+	opts := logging.Options{
+		File:       c.LogFile,
+		Level:      level,
+		Format:     c.LogFormat,
+		Append:     c.LogAppend,
+		MaxSize:    int64(c.LogMaxSize) * 1024,
+		MaxAge:     time.Duration(c.LogMaxAge) * time.Second,
+		MaxBackups: c.LogBackups,
 	}
-
-	// Configura o logger padrão para escrever no arquivo
-	log.SetOutput(logFile)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-	log.Printf("=== Iniciando logs para máquina %s ===", c.MachineName)
-
-	return nil
+	if c.LogMirror {
+		opts.Mirror = os.Stderr
+	}
+	return opts
 }
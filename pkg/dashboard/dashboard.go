@@ -0,0 +1,189 @@
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"ring-network/pkg/discovery"
+	"ring-network/pkg/network"
+)
+
+//go:embed index.html
+var page []byte
+
+// DefaultInterval é o intervalo padrão entre censos enquanto há navegadores conectados
+const DefaultInterval = time.Second
+
+// Router é onde o painel registra suas rotas (ex: api.Server ou http.ServeMux)
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Update é o estado do anel enviado aos navegadores a cada censo
+type Update struct {
+	Machine     string              `json:"maquina"`        // Estação que serve o painel
+	Stations    []discovery.Station `json:"estacoes"`       // Estações na ordem do anel
+	TokenHolder string              `json:"token"`          // Estação com o token (vazio = em trânsito)
+	RTT         float64             `json:"volta_ms"`       // Tempo de volta do censo, em milissegundos
+	Updated     time.Time           `json:"atualizado"`     // Horário do censo segundo a máquina
+	Error       string              `json:"erro,omitempty"` // Falha do censo (ex: estação desligada)
+}
+
+// Dashboard serve uma página que mostra o anel em tempo real via Server-Sent Events
+// O anel é obtido por censos periódicos, executados apenas enquanto há navegadores conectados
+type Dashboard struct {
+	machine   *network.Machine
+	discovery *discovery.Service
+	interval  time.Duration
+	clients   map[chan []byte]struct{}
+	running   bool
+	mutex     sync.Mutex
+}
+
+// New cria o painel da máquina
+func New(machine *network.Machine, disc *discovery.Service, interval time.Duration) *Dashboard {
+	return &Dashboard{
+		machine:   machine,
+		discovery: disc,
+		interval:  interval,
+		clients:   make(map[chan []byte]struct{}),
+	}
+}
+
+// Register registra a página (/) e o fluxo de eventos (/events)
+func (d *Dashboard) Register(router Router) {
+	router.Handle("GET /{$}", http.HandlerFunc(d.handlePage))
+	router.Handle("GET /events", http.HandlerFunc(d.handleEvents))
+}
+
+// handlePage serve a página do painel
+func (d *Dashboard) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// handleEvents mantém a conexão SSE de um navegador, enviando cada atualização do anel
+func (d *Dashboard) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	client := d.subscribe()
+	defer d.unsubscribe(client)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-client:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// subscribe registra um navegador e inicia os censos se for o primeiro
+func (d *Dashboard) subscribe() chan []byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	client := make(chan []byte, 1)
+	d.clients[client] = struct{}{}
+	if !d.running {
+		d.running = true
+		go d.run()
+	}
+	return client
+}
+
+// unsubscribe remove um navegador desconectado
+func (d *Dashboard) unsubscribe(client chan []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.clients, client)
+}
+
+// run executa censos periódicos enquanto houver navegadores conectados
+func (d *Dashboard) run() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.publish(d.census())
+
+		select {
+		case <-d.machine.Done():
+			d.mutex.Lock()
+			d.running = false
+			d.mutex.Unlock()
+			return
+		case <-ticker.C:
+		}
+
+		d.mutex.Lock()
+		if len(d.clients) == 0 {
+			d.running = false
+			d.mutex.Unlock()
+			return
+		}
+		d.mutex.Unlock()
+	}
+}
+
+// census executa um censo e monta a atualização para os navegadores
+func (d *Dashboard) census() Update {
+	update := Update{Machine: d.machine.Name(), Updated: d.machine.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.interval)
+	defer cancel()
+
+	ring, err := d.discovery.Discover(ctx)
+	if err != nil {
+		// Sem o retorno do censo, mostra o último anel conhecido e a falha
+		update.Error = fmt.Sprintf("censo não retornou: %v", err)
+		if last, ok := d.discovery.Last(); ok {
+			ring = last
+		}
+	}
+	update.Stations = ring.Stations
+	update.TokenHolder = ring.TokenHolder()
+	update.RTT = float64(ring.RTT.Microseconds()) / 1000
+	return update
+}
+
+// publish envia a atualização a todos os navegadores conectados
+// Um navegador lento recebe apenas a atualização mais recente
+func (d *Dashboard) publish(update Update) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		d.machine.Log(slog.LevelError, "Erro ao codificar atualização do painel: %v", err)
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for client := range d.clients {
+		select {
+		case <-client:
+		default:
+		}
+		client <- data
+	}
+}
@@ -0,0 +1,195 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+)
+
+// Station descreve o estado de uma estação no momento em que o censo passou por ela
+type Station struct {
+	Name     string `json:"maquina"`
+	HasToken bool   `json:"token"`
+	Queue    int    `json:"fila"`
+	Inbox    int    `json:"buffer_recepcao"`
+	Sent     int    `json:"enviadas"`
+	Received int    `json:"recebidas"`
+	Errors   int    `json:"erros_crc"`
+	InFlight string `json:"quadro_em_transito,omitempty"` // Quadro enviado pela estação aguardando retorno
+}
+
+// Ring é o resultado de um censo: as estações na ordem do anel a partir da origem
+type Ring struct {
+	Origin    string        `json:"origem"`
+	Stations  []Station     `json:"estacoes"`
+	RTT       time.Duration `json:"volta_ns"`
+	Completed time.Time     `json:"concluido"`
+}
+
+// TokenHolder retorna a estação que possuía o token durante o censo
+// Retorna string vazia se o token estava em trânsito entre estações
+func (r Ring) TokenHolder() string {
+	for _, station := range r.Stations {
+		if station.HasToken {
+			return station.Name
+		}
+	}
+	return ""
+}
+
+// census guarda o estado de um censo iniciado por esta estação
+type census struct {
+	sent time.Time
+	done chan struct{}
+	ring Ring
+}
+
+// Service descobre as estações do anel com um pacote de censo
+// O pacote circula sem depender do token e cada estação acrescenta o próprio estado
+// Formato: 6000;<id>:<origem>:<estações em JSON>
+type Service struct {
+	machine *network.Machine
+	pending map[string]*census
+	last    *Ring
+	counter int
+	mutex   sync.Mutex
+}
+
+// NewService cria o serviço de descoberta e registra o tratamento dos pacotes de censo na máquina
+func NewService(machine *network.Machine) (*Service, error) {
+	s := &Service{
+		machine: machine,
+		pending: make(map[string]*census),
+	}
+	if err := machine.RegisterFrameHandler(message.CensusPacket, s.handleFrame); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Discover envia um censo pelo anel e aguarda o seu retorno
+func (s *Service) Discover(ctx context.Context) (Ring, error) {
+	s.mutex.Lock()
+	s.counter++
+	id := fmt.Sprintf("%s-%d", s.machine.Name(), s.counter)
+	c := &census{sent: time.Now(), done: make(chan struct{})}
+	s.pending[id] = c
+	s.mutex.Unlock()
+
+	if err := s.send(id, s.machine.Name(), []Station{s.local()}); err != nil {
+		s.forget(id)
+		return Ring{}, err
+	}
+
+	select {
+	case <-c.done:
+		return c.ring, nil
+	case <-ctx.Done():
+		s.forget(id)
+		return Ring{}, ctx.Err()
+	}
+}
+
+// Last retorna o resultado do último censo concluído por esta estação
+func (s *Service) Last() (Ring, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.last == nil {
+		return Ring{}, false
+	}
+	return *s.last, true
+}
+
+// forget descarta um censo que não será concluído
+func (s *Service) forget(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.pending, id)
+}
+
+// handleFrame processa um pacote de censo recebido
+func (s *Service) handleFrame(data string) {
+	id, origin, stations, err := decode(data)
+	if err != nil {
+		s.machine.Log(slog.LevelError, "Erro ao parsear pacote de censo: %v", err)
+		return
+	}
+
+	if origin != s.machine.Name() {
+		// Acrescenta o estado desta estação e repassa
+		if err := s.send(id, origin, append(stations, s.local())); err != nil {
+			s.machine.Log(slog.LevelError, "Erro ao repassar censo: %v", err)
+		}
+		return
+	}
+
+	// O censo retornou à origem
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c, ok := s.pending[id]
+	if !ok {
+		return
+	}
+	delete(s.pending, id)
+
+	c.ring = Ring{
+		Origin:    origin,
+		Stations:  stations,
+		RTT:       time.Since(c.sent),
+		Completed: time.Now(),
+	}
+	s.last = &c.ring
+	close(c.done)
+}
+
+// local retorna o estado atual desta estação
+func (s *Service) local() Station {
+	status := s.machine.GetStatus()
+	return Station{
+		Name:     status.MachineName,
+		HasToken: status.HasToken,
+		Queue:    status.QueueSize,
+		Inbox:    status.InboxSize,
+		Sent:     status.MessagesSent,
+		Received: status.MessagesReceived,
+		Errors:   status.ErrorsDetected,
+		InFlight: s.machine.GetInFlight(),
+	}
+}
+
+// send envia o pacote de censo para a próxima estação
+func (s *Service) send(id, origin string, stations []Station) error {
+	encoded, err := json.Marshal(stations)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar censo: %v", err)
+	}
+	return s.machine.SendFrame(fmt.Sprintf("%s;%s:%s:%s", message.CensusPacket, id, origin, encoded))
+}
+
+// decode analisa um pacote de censo
+func decode(data string) (string, string, []Station, error) {
+	_, body, found := strings.Cut(data, ";")
+	if !found {
+		return "", "", nil, fmt.Errorf("pacote de censo sem corpo")
+	}
+	parts := strings.SplitN(body, ":", 3)
+	if len(parts) != 3 {
+		return "", "", nil, fmt.Errorf("formato de censo inválido: esperado 3 partes, obtido %d", len(parts))
+	}
+
+	var stations []Station
+	if err := json.Unmarshal([]byte(parts[2]), &stations); err != nil {
+		return "", "", nil, fmt.Errorf("estações do censo inválidas: %v", err)
+	}
+	return parts[0], parts[1], stations, nil
+}
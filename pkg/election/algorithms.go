@@ -0,0 +1,200 @@
+package election
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Algorithm implementa a lógica de um algoritmo de eleição em uma estação
+// Cada execução cria uma nova instância por estação; as mensagens de anúncio
+// e resultado são tratadas pelo Service, comum a todos os algoritmos
+type Algorithm interface {
+	// Start é chamado quando esta estação inicia a eleição (ou é acordada por uma mensagem)
+	Start() []Message
+
+	// Receive processa uma mensagem de candidato/resposta e retorna as mensagens a enviar
+	// e se esta estação descobriu ser a líder
+	Receive(msg Message) (out []Message, leader bool)
+}
+
+// Factory cria uma instância do algoritmo para a estação com o identificador informado
+type Factory func(uid string) Algorithm
+
+// algorithmInfo descreve um algoritmo registrado
+type algorithmInfo struct {
+	factory       Factory
+	bidirectional bool
+}
+
+var (
+	registry      = make(map[string]algorithmInfo)
+	registryMutex sync.RWMutex
+)
+
+// Register adiciona um algoritmo de eleição ao conjunto disponível
+// bidirectional indica se o algoritmo precisa enviar mensagens à máquina anterior
+func Register(name string, bidirectional bool, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry[name] = algorithmInfo{factory: factory, bidirectional: bidirectional}
+}
+
+// Algorithms retorna os nomes dos algoritmos registrados, em ordem alfabética
+func Algorithms() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookup retorna o algoritmo registrado com o nome informado
+func lookup(name string) (algorithmInfo, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	info, ok := registry[name]
+	if !ok {
+		return algorithmInfo{}, fmt.Errorf("algoritmo de eleição desconhecido: %s", name)
+	}
+	return info, nil
+}
+
+func init() {
+	Register("lcr", false, func(uid string) Algorithm { return &lcr{uid: uid} })
+	Register("cr", false, func(uid string) Algorithm { return &changRoberts{uid: uid} })
+	Register("hs", true, func(uid string) Algorithm { return &hirschbergSinclair{uid: uid} })
+}
+
+// lcr implementa o algoritmo de LeLann–Chang–Roberts
+// Toda estação envia seu identificador; identificadores maiores que o próprio
+// são repassados, menores são descartados. Complexidade O(n²) mensagens.
+type lcr struct {
+	uid     string
+	started bool
+}
+
+func (a *lcr) Start() []Message {
+	if a.started {
+		return nil
+	}
+	a.started = true
+	return []Message{{Kind: KindCandidate, UID: a.uid, Dir: Forward}}
+}
+
+func (a *lcr) Receive(msg Message) ([]Message, bool) {
+	// Uma estação acordada por uma mensagem também envia seu identificador
+	out := a.Start()
+
+	switch {
+	case msg.UID == a.uid:
+		return out, true
+	case msg.UID > a.uid:
+		out = append(out, Message{Kind: KindCandidate, UID: msg.UID, Dir: Forward})
+	}
+	return out, false
+}
+
+// changRoberts implementa o algoritmo de Chang–Roberts
+// Uma estação só envia o próprio identificador se ainda não participa da eleição
+// e recebe um identificador menor. Em média O(n log n) mensagens.
+type changRoberts struct {
+	uid         string
+	participant bool
+}
+
+func (a *changRoberts) Start() []Message {
+	if a.participant {
+		return nil
+	}
+	a.participant = true
+	return []Message{{Kind: KindCandidate, UID: a.uid, Dir: Forward}}
+}
+
+func (a *changRoberts) Receive(msg Message) ([]Message, bool) {
+	switch {
+	case msg.UID == a.uid:
+		return nil, true
+	case msg.UID > a.uid:
+		a.participant = true
+		return []Message{{Kind: KindCandidate, UID: msg.UID, Dir: Forward}}, false
+	default:
+		// Identificador menor: substitui pelo próprio apenas se ainda não participava
+		return a.Start(), false
+	}
+}
+
+// hirschbergSinclair implementa o algoritmo de Hirschberg–Sinclair (anel bidirecional)
+// Na fase k, cada candidato envia sondas a distância 2^k nos dois sentidos e só
+// avança de fase se as duas sondas retornarem. O(n log n) mensagens no pior caso.
+type hirschbergSinclair struct {
+	uid     string
+	started bool
+	phase   int
+	replies map[Direction]bool
+	elected bool
+}
+
+func (a *hirschbergSinclair) Start() []Message {
+	if a.started {
+		return nil
+	}
+	a.started = true
+	return a.probes()
+}
+
+// probes cria as sondas da fase atual nos dois sentidos
+func (a *hirschbergSinclair) probes() []Message {
+	a.replies = make(map[Direction]bool)
+	return []Message{
+		{Kind: KindCandidate, UID: a.uid, Phase: a.phase, Hops: 1, Dir: Forward},
+		{Kind: KindCandidate, UID: a.uid, Phase: a.phase, Hops: 1, Dir: Backward},
+	}
+}
+
+func (a *hirschbergSinclair) Receive(msg Message) ([]Message, bool) {
+	out := a.Start()
+
+	switch msg.Kind {
+	case KindCandidate:
+		switch {
+		case msg.UID == a.uid:
+			// A própria sonda percorreu o anel inteiro: esta estação é a líder
+			if a.elected {
+				return out, false
+			}
+			a.elected = true
+			return out, true
+		case msg.UID > a.uid && msg.Hops < 1<<msg.Phase:
+			// Repassa a sonda no mesmo sentido
+			out = append(out, Message{Kind: KindCandidate, UID: msg.UID, Phase: msg.Phase, Hops: msg.Hops + 1, Dir: msg.Dir})
+		case msg.UID > a.uid:
+			// Sonda atingiu a distância da fase: responde no sentido contrário
+			out = append(out, Message{Kind: KindReply, UID: msg.UID, Phase: msg.Phase, Dir: msg.Dir.Opposite()})
+		}
+
+	case KindReply:
+		if msg.UID != a.uid {
+			out = append(out, Message{Kind: KindReply, UID: msg.UID, Phase: msg.Phase, Dir: msg.Dir})
+			break
+		}
+		if msg.Phase != a.phase || a.elected {
+			break
+		}
+		// A resposta chega no sentido contrário ao da sonda que a originou
+		a.replies[msg.Dir.Opposite()] = true
+		if a.replies[Forward] && a.replies[Backward] {
+			a.phase++
+			out = append(out, a.probes()...)
+		}
+	}
+
+	return out, false
+}
@@ -0,0 +1,224 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+)
+
+// Result resume uma execução de eleição concluída
+type Result struct {
+	Run              string        // Identificador da execução
+	Algorithm        string        // Algoritmo utilizado
+	Leader           string        // Estação eleita
+	RingSize         int           // Número de estações no anel (medido pelo anúncio)
+	ElectionMessages int           // Mensagens trocadas pelo algoritmo em todas as estações
+	SentHere         int           // Mensagens do algoritmo enviadas por esta estação
+	TotalMessages    int           // Mensagens do algoritmo mais anúncio e resultado
+	Duration         time.Duration // Duração medida por esta estação desde o início
+}
+
+// String retorna uma representação em string do resultado
+func (r Result) String() string {
+	return fmt.Sprintf("eleição %s (%s): líder=%s, estações=%d, mensagens do algoritmo=%d, total com anúncio=%d, duração=%v",
+		r.Run, r.Algorithm, r.Leader, r.RingSize, r.ElectionMessages, r.TotalMessages, r.Duration.Round(time.Millisecond))
+}
+
+// run guarda o estado de uma execução de eleição nesta estação
+type run struct {
+	algorithm Algorithm
+	started   time.Time
+	sent      int
+	done      chan struct{}
+	result    *Result
+}
+
+// Service executa os algoritmos de eleição sobre os pacotes de controle da máquina
+type Service struct {
+	machine *network.Machine
+	runs    map[string]*run
+	results []Result
+	counter int
+	mutex   sync.Mutex
+}
+
+// NewService cria o serviço de eleição e registra o tratamento dos pacotes de eleição na máquina
+func NewService(machine *network.Machine) (*Service, error) {
+	s := &Service{
+		machine: machine,
+		runs:    make(map[string]*run),
+	}
+	if err := machine.RegisterFrameHandler(message.ElectionPacket, s.handleFrame); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start inicia uma eleição com o algoritmo informado a partir desta estação
+// Retorna o identificador da execução
+func (s *Service) Start(algorithm string) (string, error) {
+	info, err := lookup(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if info.bidirectional && !s.machine.IsBidirectional() {
+		return "", fmt.Errorf("algoritmo %s requer anel bidirecional (configure maquina_anterior em todas as estações)", algorithm)
+	}
+
+	s.mutex.Lock()
+	s.counter++
+	id := fmt.Sprintf("%s-%d", s.machine.Name(), s.counter)
+	r := s.newRun(id, info)
+	out := r.algorithm.Start()
+	r.sent += len(out)
+	s.mutex.Unlock()
+
+	s.machine.Logf("Eleição %s iniciada com o algoritmo %s", id, algorithm)
+	s.sendAll(Message{Run: id, Algorithm: algorithm}, out)
+	return id, nil
+}
+
+// Wait aguarda a conclusão de uma execução nesta estação
+func (s *Service) Wait(ctx context.Context, id string) (Result, error) {
+	s.mutex.Lock()
+	r, ok := s.runs[id]
+	s.mutex.Unlock()
+	if !ok {
+		return Result{}, fmt.Errorf("eleição %s desconhecida", id)
+	}
+
+	select {
+	case <-r.done:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return *r.result, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// Results retorna os resultados das eleições concluídas, da mais antiga à mais recente
+func (s *Service) Results() []Result {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Result(nil), s.results...)
+}
+
+// newRun cria o estado de uma execução nesta estação
+// Deve ser chamado com o mutex já adquirido
+func (s *Service) newRun(id string, info algorithmInfo) *run {
+	r := &run{
+		algorithm: info.factory(s.machine.Name()),
+		started:   time.Now(),
+		done:      make(chan struct{}),
+	}
+	s.runs[id] = r
+	return r
+}
+
+// handleFrame processa um pacote de eleição recebido
+func (s *Service) handleFrame(data string) {
+	msg, err := Decode(data)
+	if err != nil {
+		s.machine.Log(slog.LevelError, "Erro ao parsear pacote de eleição: %v", err)
+		return
+	}
+
+	s.mutex.Lock()
+	r, ok := s.runs[msg.Run]
+	if !ok {
+		// Estação acordada pela primeira mensagem desta execução
+		info, err := lookup(msg.Algorithm)
+		if err != nil {
+			s.mutex.Unlock()
+			s.machine.Log(slog.LevelWarn, "Eleição %s: %v", msg.Run, err)
+			return
+		}
+		r = s.newRun(msg.Run, info)
+	}
+
+	var out []Message
+	var announce []Message
+	switch msg.Kind {
+	case KindCandidate, KindReply:
+		if r.result != nil {
+			// Mensagens atrasadas de uma execução já concluída
+			s.mutex.Unlock()
+			return
+		}
+		var leader bool
+		out, leader = r.algorithm.Receive(msg)
+		r.sent += len(out)
+		if leader {
+			s.machine.Logf("Eleição %s: esta estação é a líder", msg.Run)
+			// O anúncio acumula as mensagens do algoritmo enviadas por cada estação
+			announce = []Message{{Kind: KindElected, UID: s.machine.Name(), Hops: 1, Count: r.sent}}
+		}
+
+	case KindElected:
+		if msg.UID == s.machine.Name() {
+			// Anúncio completou o ciclo: a contagem e o tamanho do anel estão completos
+			announce = []Message{{Kind: KindResult, UID: msg.UID, Hops: msg.Hops, Count: msg.Count}}
+		} else {
+			announce = []Message{{Kind: KindElected, UID: msg.UID, Hops: msg.Hops + 1, Count: msg.Count + r.sent}}
+		}
+
+	case KindResult:
+		s.finish(msg, r)
+		if msg.UID != s.machine.Name() {
+			announce = []Message{msg}
+		}
+	}
+	s.mutex.Unlock()
+
+	s.sendAll(msg, append(out, announce...))
+}
+
+// finish registra o resultado de uma execução
+// Deve ser chamado com o mutex já adquirido
+func (s *Service) finish(msg Message, r *run) {
+	if r.result != nil {
+		return
+	}
+
+	// Cada estação do anel encaminha o anúncio e o resultado uma vez
+	result := Result{
+		Run:              msg.Run,
+		Algorithm:        msg.Algorithm,
+		Leader:           msg.UID,
+		RingSize:         msg.Hops,
+		ElectionMessages: msg.Count,
+		SentHere:         r.sent,
+		TotalMessages:    msg.Count + 2*msg.Hops,
+		Duration:         time.Since(r.started),
+	}
+	r.result = &result
+	s.results = append(s.results, result)
+	close(r.done)
+
+	s.machine.Logf("Resultado da %s", result)
+}
+
+// sendAll envia mensagens de uma execução no sentido indicado em cada uma
+func (s *Service) sendAll(base Message, out []Message) {
+	for _, msg := range out {
+		msg.Run = base.Run
+		msg.Algorithm = base.Algorithm
+
+		var err error
+		if msg.Dir == Backward {
+			err = s.machine.SendFrameBackward(msg.Encode())
+		} else {
+			err = s.machine.SendFrame(msg.Encode())
+		}
+		if err != nil {
+			s.machine.Log(slog.LevelError, "Eleição %s: erro ao enviar mensagem: %v", base.Run, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package election
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ring-network/pkg/message"
+)
+
+// Tipos de mensagem de eleição
+const (
+	KindCandidate = "candidato" // Identificador candidato (sonda no Hirschberg–Sinclair)
+	KindReply     = "resposta"  // Resposta de uma sonda (apenas Hirschberg–Sinclair)
+	KindElected   = "eleito"    // Anúncio do líder, acumulando a contagem de mensagens
+	KindResult    = "resultado" // Resultado final, com o total de mensagens e o tamanho do anel
+)
+
+// Direction indica o sentido de circulação de uma mensagem no anel
+type Direction int
+
+// Sentidos de circulação
+const (
+	Forward  Direction = iota // Para a próxima máquina (sentido do token)
+	Backward                  // Para a máquina anterior (anel bidirecional)
+)
+
+// Opposite retorna o sentido contrário
+func (d Direction) Opposite() Direction {
+	if d == Forward {
+		return Backward
+	}
+	return Forward
+}
+
+// String retorna uma representação em string do sentido
+func (d Direction) String() string {
+	if d == Backward {
+		return "anterior"
+	}
+	return "proxima"
+}
+
+// Message representa um pacote de eleição
+// Formato: 3000;<execução>:<algoritmo>:<tipo>:<uid>:<fase>:<saltos>:<sentido>:<contagem>
+type Message struct {
+	Run       string    // Identificador da execução da eleição
+	Algorithm string    // Nome do algoritmo
+	Kind      string    // Tipo da mensagem
+	UID       string    // Identificador do candidato (nome da máquina)
+	Phase     int       // Fase (Hirschberg–Sinclair)
+	Hops      int       // Saltos percorridos
+	Dir       Direction // Sentido de circulação
+	Count     int       // Contagem de mensagens acumulada (anúncio e resultado)
+}
+
+// Encode converte a mensagem para o formato do pacote
+func (msg Message) Encode() string {
+	return fmt.Sprintf("%s;%s:%s:%s:%s:%d:%d:%d:%d", message.ElectionPacket,
+		msg.Run, msg.Algorithm, msg.Kind, msg.UID, msg.Phase, msg.Hops, msg.Dir, msg.Count)
+}
+
+// Decode analisa um pacote de eleição recebido
+func Decode(data string) (Message, error) {
+	_, body, found := strings.Cut(data, ";")
+	if !found || message.PacketType(data) != message.ElectionPacket {
+		return Message{}, fmt.Errorf("não é um pacote de eleição válido")
+	}
+
+	parts := strings.Split(body, ":")
+	if len(parts) != 8 {
+		return Message{}, fmt.Errorf("formato de pacote de eleição inválido: esperado 8 partes, obtido %d", len(parts))
+	}
+
+	numbers := make([]int, 4)
+	for i, part := range parts[4:] {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Message{}, fmt.Errorf("campo numérico inválido no pacote de eleição: %v", err)
+		}
+		numbers[i] = n
+	}
+
+	return Message{
+		Run:       parts[0],
+		Algorithm: parts[1],
+		Kind:      parts[2],
+		UID:       parts[3],
+		Phase:     numbers[0],
+		Hops:      numbers[1],
+		Dir:       Direction(numbers[2]),
+		Count:     numbers[3],
+	}, nil
+}
@@ -0,0 +1,121 @@
+package kvstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ring-network/pkg/network"
+)
+
+// Prefixo e operações das mensagens do armazenamento replicado
+// Formato do conteúdo: "kv PUT <chave> <valor>" ou "kv DEL <chave>"
+const (
+	messagePrefix = "kv"
+	opPut         = "PUT"
+	opDelete      = "DEL"
+)
+
+// Store implementa um armazenamento chave-valor replicado em todas as estações do anel
+// As escritas são enviadas pela difusão com ordem total, de modo que todas as
+// estações aplicam as operações na mesma ordem e mantêm cópias idênticas
+type Store struct {
+	machine    *network.Machine  // Máquina usada para difundir as operações
+	data       map[string]string // Cópia local dos dados
+	appliedSeq int               // Número de sequência da última operação aplicada
+	mutex      sync.RWMutex      // Mutex para acesso concorrente
+}
+
+// New cria o armazenamento e o registra para receber as entregas ordenadas da máquina
+func New(machine *network.Machine) *Store {
+	s := &Store{
+		machine: machine,
+		data:    make(map[string]string),
+	}
+	machine.OnOrderedDelivery(s.apply)
+	return s
+}
+
+// Put difunde a gravação de uma chave
+// A alteração só é visível localmente quando a operação for entregue na ordem total
+func (s *Store) Put(key, value string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return s.machine.BroadcastOrdered(fmt.Sprintf("%s %s %s %s", messagePrefix, opPut, key, value))
+}
+
+// Delete difunde a remoção de uma chave
+func (s *Store) Delete(key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return s.machine.BroadcastOrdered(fmt.Sprintf("%s %s %s", messagePrefix, opDelete, key))
+}
+
+// Get retorna o valor de uma chave na cópia local
+func (s *Store) Get(key string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, ok := s.data[key]
+	return value, ok
+}
+
+// Keys retorna as chaves da cópia local em ordem alfabética
+func (s *Store) Keys() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// AppliedSeq retorna o número de sequência da última operação aplicada
+// Estações com o mesmo número possuem cópias idênticas dos dados
+func (s *Store) AppliedSeq() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.appliedSeq
+}
+
+// apply aplica uma operação entregue pela difusão com ordem total
+// Mensagens que não pertencem ao armazenamento são ignoradas
+func (s *Store) apply(msg network.OrderedMessage) {
+	parts := strings.SplitN(msg.Content, " ", 4)
+	if len(parts) < 3 || parts[0] != messagePrefix {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch parts[1] {
+	case opPut:
+		value := ""
+		if len(parts) == 4 {
+			value = parts[3]
+		}
+		s.data[parts[2]] = value
+	case opDelete:
+		delete(s.data, parts[2])
+	default:
+		return
+	}
+	s.appliedSeq = msg.Seq
+}
+
+// validateKey verifica se a chave pode ser transmitida no formato das mensagens
+func validateKey(key string) error {
+	if key == "" || strings.ContainsAny(key, " \t\n") {
+		return fmt.Errorf("chave inválida: %q", key)
+	}
+	return nil
+}
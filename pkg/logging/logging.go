@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Formatos de saída das linhas de log
+const (
+	FormatText = "texto" // chave=valor, legível no terminal
+	FormatJSON = "json"  // Um objeto JSON por linha, para ferramentas de análise
+)
+
+// Options descreve onde e como as linhas de log são gravadas
+type Options struct {
+	File       string        // Arquivo de log (vazio = sem arquivo)
+	Level      slog.Level    // Nível mínimo das linhas gravadas
+	Format     string        // FormatText ou FormatJSON (vazio = FormatText)
+	Append     bool          // Acrescenta ao arquivo existente em vez de truncá-lo
+	MaxSize    int64         // Tamanho em bytes a partir do qual o arquivo é rotacionado (0 = sem limite)
+	MaxAge     time.Duration // Idade a partir da qual o arquivo é rotacionado (0 = sem limite)
+	MaxBackups int           // Número de arquivos rotacionados mantidos (0 = todos)
+	Mirror     io.Writer     // Cópia das linhas, ex: os.Stderr (nil = sem cópia)
+}
+
+// New cria o logger descrito pelas opções
+// O io.Closer retornado fecha o arquivo de log e deve ser chamado ao final do programa
+// Sem arquivo e sem cópia, as linhas são descartadas
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	var writers []io.Writer
+	var closer io.Closer = nopCloser{}
+
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, opts.Append, opts.MaxSize, opts.MaxAge, opts.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		writers = append(writers, file)
+		closer = file
+	}
+	if opts.Mirror != nil {
+		writers = append(writers, opts.Mirror)
+	}
+	if len(writers) == 0 {
+		return slog.New(slog.DiscardHandler), closer, nil
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: opts.Level}
+	output := io.MultiWriter(writers...)
+
+	var handler slog.Handler
+	switch opts.Format {
+	case "", FormatText:
+		handler = slog.NewTextHandler(output, handlerOptions)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("formato de log desconhecido: %s", opts.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel interpreta o nome de um nível de log (debug, info, aviso ou erro)
+// Os nomes em inglês (warn, error) também são aceitos
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "aviso", "warn":
+		return slog.LevelWarn, nil
+	case "erro", "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("nível de log desconhecido: %s", name)
+	}
+}
+
+// ValidateFormat verifica se o formato de log é conhecido
+func ValidateFormat(format string) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("formato de log desconhecido: %s", format)
+	}
+	return nil
+}
+
+// nopCloser é o io.Closer retornado quando não há arquivo a fechar
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout é o formato do horário acrescentado ao nome dos arquivos rotacionados
+// (ex: alice_log.txt.20260102-150405.000); a ordem alfabética é a ordem cronológica
+const backupLayout = "20060102-150405.000"
+
+// RotatingFile é um arquivo de log que é renomeado e recriado ao atingir um tamanho ou idade
+type RotatingFile struct {
+	path       string        // Caminho do arquivo atual
+	maxSize    int64         // Tamanho máximo em bytes (0 = sem limite)
+	maxAge     time.Duration // Idade máxima desde a abertura (0 = sem limite)
+	maxBackups int           // Arquivos rotacionados mantidos (0 = todos)
+	file       *os.File      // Arquivo atual
+	size       int64         // Tamanho atual do arquivo
+	opened     time.Time     // Horário de abertura do arquivo atual
+	lastBackup time.Time     // Horário do último arquivo rotacionado (os nomes não se repetem)
+	closed     bool          // Indica se Close foi chamado
+	mutex      sync.Mutex    // Protege o arquivo (as linhas chegam de várias goroutines)
+}
+
+// OpenRotatingFile abre o arquivo de log, criando o diretório se necessário
+// Com appendMode o conteúdo existente é preservado; caso contrário o arquivo é truncado
+func OpenRotatingFile(path string, appendMode bool, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	// Cria o diretório de logs se necessário
+	dir := filepath.Dir(path)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("erro ao criar diretório de logs: %v", err)
+		}
+	}
+
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(appendMode); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open abre o arquivo atual, acrescentando ou truncando
+func (r *RotatingFile) open(appendMode bool) error {
+	flags := os.O_CREATE | os.O_WRONLY
+	if appendMode {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(r.path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo de log: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("erro ao abrir arquivo de log: %v", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+// Write grava uma linha, rotacionando o arquivo antes se o limite de tamanho ou idade foi atingido
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	// Uma rotação anterior pode ter deixado o arquivo fechado: tenta reabri-lo
+	if r.file == nil {
+		if err := r.open(true); err != nil {
+			return 0, err
+		}
+	}
+
+	// Se a rotação falhar, a linha continua no arquivo atual e o erro é retornado
+	var rotateErr error
+	if r.needsRotation(len(p)) {
+		rotateErr = r.rotate()
+		if r.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// needsRotation indica se a próxima linha deve ir para um arquivo novo
+// Um arquivo vazio nunca é rotacionado, mesmo que a linha sozinha exceda o limite
+func (r *RotatingFile) needsRotation(next int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+int64(next) > r.maxSize {
+		return true
+	}
+	return r.maxAge > 0 && time.Since(r.opened) >= r.maxAge
+}
+
+// rotate renomeia o arquivo atual com o horário, abre um novo e remove os excedentes
+// Em caso de falha, reabre o arquivo original para acrescentar, para que o log não pare
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return r.reopen(fmt.Errorf("erro ao fechar arquivo de log: %v", err))
+	}
+
+	backup := r.backupName()
+	if err := os.Rename(r.path, backup); err != nil {
+		return r.reopen(fmt.Errorf("erro ao rotacionar arquivo de log: %v", err))
+	}
+	if err := r.open(false); err != nil {
+		return r.reopen(err)
+	}
+	return r.removeOldBackups()
+}
+
+// reopen reabre o arquivo atual para acrescentar depois de uma rotação que falhou
+// Retorna o erro da rotação (ou também o da reabertura)
+func (r *RotatingFile) reopen(cause error) error {
+	if err := r.open(true); err != nil {
+		return fmt.Errorf("%v; %v", cause, err)
+	}
+	return cause
+}
+
+// backupName retorna um nome ainda não usado para o arquivo rotacionado
+// Duas rotações no mesmo milissegundo recebem horários consecutivos, sem sobrescrever um arquivo
+func (r *RotatingFile) backupName() string {
+	stamp := time.Now().Truncate(time.Millisecond)
+	if !stamp.After(r.lastBackup) {
+		stamp = r.lastBackup.Add(time.Millisecond)
+	}
+	for {
+		backup := r.path + "." + stamp.Format(backupLayout)
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			r.lastBackup = stamp
+			return backup
+		}
+		stamp = stamp.Add(time.Millisecond)
+	}
+}
+
+// removeOldBackups mantém apenas os maxBackups arquivos rotacionados mais recentes
+func (r *RotatingFile) removeOldBackups() error {
+	if r.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := r.Backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > r.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("erro ao remover arquivo de log antigo: %v", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Backups retorna os arquivos rotacionados, do mais antigo para o mais recente
+func (r *RotatingFile) Backups() ([]string, error) {
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar arquivos de log: %v", err)
+	}
+
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, r.path+".")
+		if _, err := time.Parse(backupLayout, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Close fecha o arquivo atual
+func (r *RotatingFile) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
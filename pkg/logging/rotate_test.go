@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRotatingFileKeepsEveryBackup rotaciona várias vezes seguidas, em geral no mesmo milissegundo
+func TestRotatingFileKeepsEveryBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	r, err := OpenRotatingFile(path, false, 4, 0, 0)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer r.Close()
+
+	const lines = 20
+	for range lines {
+		if _, err := r.Write([]byte("abc\n")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	backups, err := r.Backups()
+	if err != nil {
+		t.Fatalf("Backups: %v", err)
+	}
+	if len(backups) != lines-1 {
+		t.Errorf("%d arquivos rotacionados, esperava %d", len(backups), lines-1)
+	}
+}
+
+// TestRotatingFileRecoversFromFailedRotation remove o arquivo atual para a rotação falhar
+// e verifica que as linhas seguintes continuam sendo gravadas
+func TestRotatingFileRecoversFromFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	r, err := OpenRotatingFile(path, false, 4, 0, 0)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Write([]byte("abc\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := r.Write([]byte("def\n")); err == nil {
+		t.Errorf("Write deveria retornar o erro da rotação")
+	}
+	if _, err := r.Write([]byte("ghi\n")); err != nil {
+		t.Fatalf("Write depois da falha: %v", err)
+	}
+
+	// As linhas gravadas depois da falha estão no arquivo atual ou em um rotacionado
+	backups, err := r.Backups()
+	if err != nil {
+		t.Fatalf("Backups: %v", err)
+	}
+	var all strings.Builder
+	for _, file := range append(backups, path) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		all.Write(data)
+	}
+	if !strings.Contains(all.String(), "def\n") || !strings.Contains(all.String(), "ghi\n") {
+		t.Errorf("linhas perdidas depois da falha: %q", all.String())
+	}
+}
@@ -0,0 +1,79 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nomes dos tipos de pacote usados nas descrições
+var packetNames = map[string]string{
+	TokenPacket:    "TOKEN",
+	DataPacket:     "DADOS",
+	ElectionPacket: "ELEIÇÃO",
+	SnapshotPacket: "SNAPSHOT",
+	TimeSyncPacket: "SINCRONIZAÇÃO",
+	CensusPacket:   "CENSO",
+	EchoPacket:     "PING",
+	TracePacket:    "TRACE",
+}
+
+// PacketName retorna o nome de um tipo de pacote (ex: "TOKEN" para 1000)
+// Tipos desconhecidos são exibidos pelo identificador (ex: "tipo 7000")
+func PacketName(packetType string) string {
+	if name, known := packetNames[packetType]; known {
+		return name
+	}
+	return "tipo " + packetType
+}
+
+// Describe retorna uma descrição legível de um pacote, para exibir o tráfego da rede
+// Ex: "DADOS Alice→Bob [ACK] \"oi\" lc=7" ou "TOKEN seq=3 lc=5"
+func Describe(data string) string {
+	packetType := PacketType(data)
+	name := PacketName(packetType)
+
+	var text string
+	switch packetType {
+	case TokenPacket:
+		text = name
+		if seq := Attr(data, AttrSeq); seq != "" {
+			text += " seq=" + seq
+		}
+
+	case DataPacket:
+		dataMsg, err := ParseDataPacket(data)
+		if err != nil {
+			return fmt.Sprintf("%s inválido: %v", name, err)
+		}
+		text = fmt.Sprintf("%s %s→%s [%s] %q", name, dataMsg.Origin, dataMsg.Destination, dataMsg.Control, dataMsg.Message)
+		if dataMsg.Seq > 0 {
+			text += fmt.Sprintf(" seq=%d", dataMsg.Seq)
+		}
+
+	case EchoPacket, TracePacket:
+		probe, err := ParseProbe(data)
+		if err != nil {
+			return fmt.Sprintf("%s inválido: %v", name, err)
+		}
+		text = fmt.Sprintf("%s %s→%s saltos=%d", name, probe.Origin, probe.Destination, probe.Hops)
+		if probe.Reached > 0 {
+			text += fmt.Sprintf(" (destino em %d)", probe.Reached)
+		}
+
+	default:
+		// Pacotes de controle: exibe apenas os campos iniciais do corpo (identificadores)
+		text = name
+		if _, body := splitPacket(data); body != nil {
+			fields := strings.SplitN(*body, ":", 4)
+			if len(fields) > 3 {
+				fields = fields[:3]
+			}
+			text += " " + strings.Join(fields, ":")
+		}
+	}
+
+	if lamport := Attr(data, AttrLamport); lamport != "" {
+		text += " lc=" + lamport
+	}
+	return text
+}
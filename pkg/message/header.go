@@ -0,0 +1,83 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Os pacotes podem carregar atributos opcionais no cabeçalho, logo após o tipo:
+//
+//	<tipo>|chave=valor|chave=valor;<corpo>
+//
+// Pacotes sem atributos mantêm o formato original (ex: "1000" ou "2000;...").
+// Os atributos não entram no cálculo do CRC, assim como o campo de controle.
+
+// Constantes para os atributos de cabeçalho
+const (
+	AttrSeq     = "seq" // Número de sequência da difusão com ordem total
+	AttrLamport = "lc"  // Relógio de Lamport da estação que enviou o quadro
+	AttrVector  = "vc"  // Relógio vetorial da estação que enviou o quadro (opcional)
+)
+
+// PacketType retorna o identificador do tipo de pacote, ignorando os atributos de cabeçalho
+func PacketType(data string) string {
+	header, _ := splitPacket(data)
+	packetType, _, _ := strings.Cut(header, "|")
+	return packetType
+}
+
+// Attr retorna o valor de um atributo de cabeçalho
+// Retorna string vazia se o atributo não estiver presente
+func Attr(data, key string) string {
+	header, _ := splitPacket(data)
+	for _, attr := range strings.Split(header, "|")[1:] {
+		k, v, _ := strings.Cut(attr, "=")
+		if k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// IntAttr retorna o valor numérico de um atributo de cabeçalho
+// Retorna 0 se o atributo não estiver presente ou for inválido
+func IntAttr(data, key string) int {
+	value, err := strconv.Atoi(Attr(data, key))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// SetAttr define (ou substitui) um atributo no cabeçalho do pacote
+// Um valor vazio remove o atributo
+func SetAttr(data, key, value string) string {
+	header, body := splitPacket(data)
+	parts := strings.Split(header, "|")
+
+	attrs := []string{parts[0]}
+	for _, attr := range parts[1:] {
+		if k, _, _ := strings.Cut(attr, "="); k != key {
+			attrs = append(attrs, attr)
+		}
+	}
+	if value != "" {
+		attrs = append(attrs, key+"="+value)
+	}
+
+	header = strings.Join(attrs, "|")
+	if body == nil {
+		return header
+	}
+	return header + ";" + *body
+}
+
+// splitPacket separa o cabeçalho (tipo e atributos) do corpo do pacote
+// O corpo é nil para pacotes sem corpo, como o token
+func splitPacket(data string) (string, *string) {
+	header, body, found := strings.Cut(data, ";")
+	if !found {
+		return header, nil
+	}
+	return header, &body
+}
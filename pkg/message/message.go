@@ -12,8 +12,14 @@ import (
 
 // Constantes para identificação dos tipos de pacotes
 const (
-	TokenPacket = "1000" // Identificador do pacote de token
-	DataPacket  = "2000" // Identificador do pacote de dados
+	TokenPacket    = "1000" // Identificador do pacote de token
+	DataPacket     = "2000" // Identificador do pacote de dados
+	ElectionPacket = "3000" // Identificador do pacote de controle de eleição de líder
+	SnapshotPacket = "4000" // Identificador do marcador de snapshot distribuído
+	TimeSyncPacket = "5000" // Identificador do pacote de sincronização de relógios
+	CensusPacket   = "6000" // Identificador do pacote de descoberta das estações do anel
+	EchoPacket     = "7000" // Identificador do pacote de ping (eco até uma estação)
+	TracePacket    = "8000" // Identificador do pacote de trace (rota até uma estação)
 )
 
 // Constantes para os campos de controle das mensagens
@@ -21,6 +27,16 @@ const (
 	ControlMachineNotExists = "maquinanaoexiste" // Indica que a máquina de destino não existe
 	ControlACK              = "ACK"              // Confirmação positiva de recebimento
 	ControlNAK              = "NAK"              // Confirmação negativa (erro detectado)
+	ControlBusy             = "BUSY"             // Receptor ocupado (buffer de recepção cheio)
+	ControlStore            = "guardar"          // Origem pede que uma caixa postal guarde a mensagem
+	ControlDeferred         = "adiado"           // Mensagem guardada pela caixa postal para entrega posterior
+	ControlRelay            = "caixapostal"      // Mensagem reenviada pela caixa postal em nome da origem
+)
+
+// Constantes para os endereços especiais de destino
+const (
+	BroadcastAddress = "TODOS" // Destino usado para mensagens enviadas a todas as máquinas
+	GroupPrefix      = "@"     // Prefixo dos endereços de grupo multicast (ex: @turma)
 )
 
 // QueuedMessage representa uma mensagem na fila para envio
@@ -28,7 +44,25 @@ type QueuedMessage struct {
 	Destination string    // Destino da mensagem
 	Content     string    // Conteúdo da mensagem
 	Timestamp   time.Time // Momento de criação da mensagem
+	Sent        time.Time // Momento da primeira transmissão (zero = ainda não transmitida)
 	Retries     int       // Número de tentativas de envio
+	Delivered   []string  // Estações que já confirmaram o recebimento (broadcast)
+	Ordered     bool      // Indica se é uma difusão com ordem total
+	Seq         int       // Número de sequência atribuído à difusão com ordem total (0 = ainda não atribuído)
+}
+
+// Receipt representa a confirmação de uma estação para um quadro de broadcast
+type Receipt struct {
+	Station string // Nome da estação
+	Status  string // ACK, NAK ou BUSY
+}
+
+// ReceivedMessage representa uma mensagem entregue a esta máquina
+// e mantida no buffer de recepção até ser lida pela aplicação
+type ReceivedMessage struct {
+	Origin    string    // Origem da mensagem
+	Content   string    // Conteúdo da mensagem
+	Timestamp time.Time // Momento do recebimento
 }
 
 // DataMessage representa um pacote de dados para transmissão na rede
@@ -39,6 +73,7 @@ type DataMessage struct {
 	Control     string // Campo de controle (ACK, NAK, etc.)
 	CRC         string // Valor CRC32 para verificação de integridade
 	Message     string // Conteúdo da mensagem
+	Seq         int    // Número de sequência da difusão com ordem total (0 = sem ordem)
 	RawData     string // Representação em string do pacote completo
 }
 
@@ -52,6 +87,15 @@ func NewQueuedMessage(destination, content string) *QueuedMessage {
 	}
 }
 
+// NewReceivedMessage cria uma nova mensagem para o buffer de recepção
+func NewReceivedMessage(origin, content string) *ReceivedMessage {
+	return &ReceivedMessage{
+		Origin:    origin,
+		Content:   content,
+		Timestamp: time.Now(),
+	}
+}
+
 // CreateDataPacket cria um novo pacote de dados para envio na rede
 // Calcula o CRC32 para verificação de integridade
 func CreateDataPacket(origin, destination, message string) *DataMessage {
@@ -62,34 +106,153 @@ func CreateDataPacket(origin, destination, message string) *DataMessage {
 	// Este valor será alterado pelo destinatário ao receber a mensagem
 	control := ControlMachineNotExists
 
-	// Formata o pacote completo como string
-	rawData := fmt.Sprintf("%s;%s:%s:%s:%s:%s",
-		DataPacket, origin, destination, control, crcValue, message)
-
-	return &DataMessage{
+	dm := &DataMessage{
 		Type:        DataPacket,
 		Origin:      origin,
 		Destination: destination,
 		Control:     control,
 		CRC:         crcValue,
 		Message:     message,
-		RawData:     rawData,
 	}
+
+	// Formata o pacote completo como string
+	dm.updateRawData()
+	return dm
+}
+
+// CreateOrderedPacket cria um pacote de difusão com ordem total
+// O número de sequência segue no cabeçalho e define a ordem de entrega em todas as estações
+func CreateOrderedPacket(origin, message string, seq int) *DataMessage {
+	dm := CreateDataPacket(origin, BroadcastAddress, message)
+	dm.Seq = seq
+	dm.updateRawData()
+	return dm
+}
+
+// IsOrdered verifica se o pacote é uma difusão com ordem total
+func (dm *DataMessage) IsOrdered() bool {
+	return dm.Seq > 0
+}
+
+// IsBroadcast verifica se o pacote é destinado a todas as máquinas
+func (dm *DataMessage) IsBroadcast() bool {
+	return dm.Destination == BroadcastAddress
+}
+
+// IsMulticast verifica se o pacote é destinado a um grupo multicast
+func (dm *DataMessage) IsMulticast() bool {
+	return IsGroupAddress(dm.Destination)
+}
+
+// HasReceipts verifica se o pacote carrega lista de confirmações por estação
+// Isso ocorre em broadcasts e em mensagens para grupos multicast
+func (dm *DataMessage) HasReceipts() bool {
+	return dm.IsBroadcast() || dm.IsMulticast()
+}
+
+// IsGroupAddress verifica se um destino é um endereço de grupo multicast
+func IsGroupAddress(destination string) bool {
+	return strings.HasPrefix(destination, GroupPrefix) && len(destination) > len(GroupPrefix)
+}
+
+// GroupAddress retorna o endereço de destino correspondente a um nome de grupo
+func GroupAddress(group string) string {
+	return GroupPrefix + strings.TrimPrefix(group, GroupPrefix)
+}
+
+// Receipts retorna a lista de confirmações por estação de um quadro de broadcast ou multicast
+// Nesses quadros o campo de controle carrega a lista no formato "Bob=ACK,Carol=NAK"
+func (dm *DataMessage) Receipts() []Receipt {
+	return ParseReceipts(dm.Control)
+}
+
+// Receipt retorna o estado registrado por uma estação no quadro de broadcast
+// Retorna string vazia se a estação ainda não registrou confirmação
+func (dm *DataMessage) Receipt(station string) string {
+	for _, r := range dm.Receipts() {
+		if r.Station == station {
+			return r.Status
+		}
+	}
+	return ""
+}
+
+// SetReceipt registra (ou substitui) a confirmação de uma estação no quadro de broadcast
+func (dm *DataMessage) SetReceipt(station, status string) {
+	receipts := dm.Receipts()
+	found := false
+	for i := range receipts {
+		if receipts[i].Station == station {
+			receipts[i].Status = status
+			found = true
+		}
+	}
+	if !found {
+		receipts = append(receipts, Receipt{Station: station, Status: status})
+	}
+	dm.SetControl(FormatReceipts(receipts))
+}
+
+// ParseReceipts converte o campo de controle de um broadcast em lista de confirmações
+// O valor inicial (maquinanaoexiste) corresponde a uma lista vazia
+func ParseReceipts(control string) []Receipt {
+	if control == "" || control == ControlMachineNotExists {
+		return nil
+	}
+
+	var receipts []Receipt
+	for _, item := range strings.Split(control, ",") {
+		station, status, ok := strings.Cut(item, "=")
+		if !ok || station == "" {
+			continue
+		}
+		receipts = append(receipts, Receipt{Station: station, Status: status})
+	}
+	return receipts
+}
+
+// FormatReceipts converte a lista de confirmações para o formato do campo de controle
+func FormatReceipts(receipts []Receipt) string {
+	if len(receipts) == 0 {
+		return ControlMachineNotExists
+	}
+
+	items := make([]string, len(receipts))
+	for i, r := range receipts {
+		items[i] = r.Station + "=" + r.Status
+	}
+	return strings.Join(items, ",")
+}
+
+// RelayControl retorna o campo de controle da resposta a uma mensagem reenviada pela caixa postal
+// Mantém o prefixo para que o quadro retorne à caixa postal, e não à origem original
+func RelayControl(status string) string {
+	return ControlRelay + "/" + status
+}
+
+// IsRelayControl verifica se o campo de controle pertence a uma mensagem reenviada pela caixa postal
+func IsRelayControl(control string) bool {
+	return control == ControlRelay || strings.HasPrefix(control, ControlRelay+"/")
+}
+
+// RelayStatus retorna a resposta do destino a uma mensagem reenviada pela caixa postal
+// Retorna string vazia se o destino ainda não respondeu
+func RelayStatus(control string) string {
+	_, status, _ := strings.Cut(control, "/")
+	return status
 }
 
 // ParseDataPacket analisa uma string recebida e converte para um objeto DataMessage
 // Retorna erro se o formato não for válido
 func ParseDataPacket(data string) (*DataMessage, error) {
 	// Verifica se começa com o identificador de pacote de dados
-	if !strings.HasPrefix(data, DataPacket+";") {
+	header, content := splitPacket(data)
+	if PacketType(header) != DataPacket || content == nil {
 		return nil, fmt.Errorf("não é um pacote de dados válido")
 	}
 
-	// Remove o prefixo do tipo de pacote
-	content := strings.TrimPrefix(data, DataPacket+";")
-
 	// Divide o conteúdo em partes usando ":" como separador
-	parts := strings.SplitN(content, ":", 5)
+	parts := strings.SplitN(*content, ":", 5)
 	if len(parts) != 5 {
 		return nil, fmt.Errorf("formato de pacote inválido: esperado 5 partes, obtido %d", len(parts))
 	}
@@ -102,13 +265,15 @@ func ParseDataPacket(data string) (*DataMessage, error) {
 		Control:     parts[2],
 		CRC:         parts[3],
 		Message:     parts[4],
+		Seq:         IntAttr(header, AttrSeq),
 		RawData:     data,
 	}, nil
 }
 
 // IsTokenPacket verifica se uma string recebida é um pacote de token
 func IsTokenPacket(data string) bool {
-	return strings.TrimSpace(data) == TokenPacket
+	data = strings.TrimSpace(data)
+	return PacketType(data) == TokenPacket && !strings.Contains(data, ";")
 }
 
 // CreateTokenPacket cria um novo pacote de token
@@ -116,6 +281,21 @@ func CreateTokenPacket() string {
 	return TokenPacket
 }
 
+// CreateSequencedTokenPacket cria um pacote de token que carrega o último número
+// de sequência atribuído a uma difusão com ordem total
+// O token funciona como sequenciador: só quem o possui atribui o próximo número
+func CreateSequencedTokenPacket(seq int) string {
+	if seq <= 0 {
+		return CreateTokenPacket()
+	}
+	return SetAttr(TokenPacket, AttrSeq, strconv.Itoa(seq))
+}
+
+// TokenSeq retorna o número de sequência carregado pelo token (0 se ausente)
+func TokenSeq(data string) int {
+	return IntAttr(strings.TrimSpace(data), AttrSeq)
+}
+
 // VerifyIntegrity verifica a integridade da mensagem usando CRC32
 // Retorna true se o CRC calculado corresponder ao CRC armazenado na mensagem
 func (dm *DataMessage) VerifyIntegrity() bool {
@@ -126,31 +306,38 @@ func (dm *DataMessage) VerifyIntegrity() bool {
 // SetControl atualiza o campo de controle da mensagem e recria o pacote raw
 func (dm *DataMessage) SetControl(control string) {
 	dm.Control = control
+	dm.updateRawData()
+}
+
+// updateRawData recria a representação em string do pacote a partir dos campos
+func (dm *DataMessage) updateRawData() {
 	dm.RawData = fmt.Sprintf("%s;%s:%s:%s:%s:%s",
-		DataPacket, dm.Origin, dm.Destination, control, dm.CRC, dm.Message)
+		DataPacket, dm.Origin, dm.Destination, dm.Control, dm.CRC, dm.Message)
+	if dm.Seq > 0 {
+		dm.RawData = SetAttr(dm.RawData, AttrSeq, strconv.Itoa(dm.Seq))
+	}
 }
 
 // IntroduceError introduz um erro na mensagem com uma probabilidade definida
-// Modifica o CRC para simular corrupção de dados
-func (dm *DataMessage) IntroduceError(probability float64) bool {
-	if rand.Float64() < probability {
+// Modifica o CRC para simular corrupção de dados, sorteando com o gerador informado
+func (dm *DataMessage) IntroduceError(rng *rand.Rand, probability float64) bool {
+	if rng.Float64() < probability {
 		// Guarda o CRC original
 		originalCRC := dm.CRC
 
 		// Gera um novo CRC aleatório
-		corruptedCRC := strconv.FormatUint(uint64(rand.Uint32()), 10)
+		corruptedCRC := strconv.FormatUint(uint64(rng.Uint32()), 10)
 
 		// Garante que o CRC corrompido seja diferente do original
 		for corruptedCRC == originalCRC {
-			corruptedCRC = strconv.FormatUint(uint64(rand.Uint32()), 10)
+			corruptedCRC = strconv.FormatUint(uint64(rng.Uint32()), 10)
 		}
 
 		// Substitui o CRC pelo valor corrompido
 		dm.CRC = corruptedCRC
 
 		// Atualiza o pacote raw com o CRC corrompido
-		dm.RawData = fmt.Sprintf("%s;%s:%s:%s:%s:%s",
-			DataPacket, dm.Origin, dm.Destination, dm.Control, dm.CRC, dm.Message)
+		dm.updateRawData()
 
 		return true
 	}
@@ -159,6 +346,10 @@ func (dm *DataMessage) IntroduceError(probability float64) bool {
 
 // String retorna uma representação em string do objeto DataMessage
 func (dm *DataMessage) String() string {
+	if dm.IsOrdered() {
+		return fmt.Sprintf("DataMessage{Origin: %s, Destination: %s, Control: %s, Seq: %d, Message: %s}",
+			dm.Origin, dm.Destination, dm.Control, dm.Seq, dm.Message)
+	}
 	return fmt.Sprintf("DataMessage{Origin: %s, Destination: %s, Control: %s, Message: %s}",
 		dm.Origin, dm.Destination, dm.Control, dm.Message)
 }
@@ -168,3 +359,8 @@ func (qm *QueuedMessage) String() string {
 	return fmt.Sprintf("QueuedMessage{Destination: %s, Content: %s, Retries: %d}",
 		qm.Destination, qm.Content, qm.Retries)
 }
+
+// String retorna uma representação em string do objeto ReceivedMessage
+func (rm *ReceivedMessage) String() string {
+	return fmt.Sprintf("ReceivedMessage{Origin: %s, Content: %s}", rm.Origin, rm.Content)
+}
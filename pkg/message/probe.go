@@ -0,0 +1,120 @@
+package message
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Probe representa um pacote de diagnóstico (ping ou trace)
+// O pacote circula sem depender do token e dá uma volta completa no anel:
+// cada estação conta o enlace percorrido e o destino registra em quantos enlaces foi alcançado
+// Formato: <tipo>;<id>:<origem>:<destino>:<saltos>:<saltos até o destino>[:<caminho>]
+// O caminho (apenas trace) lista as estações com o horário de chegada: Alice@<ns>,Bob@<ns>,...
+type Probe struct {
+	Type        string // Tipo do pacote (EchoPacket ou TracePacket)
+	ID          string // Identificador atribuído pela origem
+	Origin      string // Estação que enviou o pacote
+	Destination string // Estação que deve ser alcançada
+	Hops        int    // Enlaces percorridos até agora
+	Reached     int    // Enlaces da origem até o destino (0 = destino ainda não alcançado)
+	Path        []Hop  // Estações por onde o pacote passou, a partir da origem (apenas trace)
+}
+
+// Hop representa a passagem de um pacote de trace por uma estação
+type Hop struct {
+	Station string    // Nome da estação
+	Time    time.Time // Horário de chegada, pelo relógio da estação
+}
+
+// CreateProbe cria um pacote de ping (EchoPacket) ou trace (TracePacket)
+// No trace, a origem é a primeira estação do caminho, com o horário de envio
+func CreateProbe(packetType, id, origin, destination string, sent time.Time) *Probe {
+	p := &Probe{Type: packetType, ID: id, Origin: origin, Destination: destination}
+	if packetType == TracePacket {
+		p.Path = []Hop{{Station: origin, Time: sent}}
+	}
+	return p
+}
+
+// IsProbePacket verifica se uma string recebida é um pacote de ping ou trace
+func IsProbePacket(data string) bool {
+	packetType := PacketType(data)
+	return packetType == EchoPacket || packetType == TracePacket
+}
+
+// Arrive registra a chegada do pacote a uma estação
+// Retorna true se a estação é o destino, alcançado pela primeira vez
+func (p *Probe) Arrive(station string, t time.Time) bool {
+	p.Hops++
+	if p.Type == TracePacket {
+		p.Path = append(p.Path, Hop{Station: station, Time: t})
+	}
+	if station == p.Destination && p.Reached == 0 {
+		p.Reached = p.Hops
+		return true
+	}
+	return false
+}
+
+// String retorna o pacote no formato de transmissão
+func (p *Probe) String() string {
+	data := fmt.Sprintf("%s;%s:%s:%s:%d:%d", p.Type, p.ID, p.Origin, p.Destination, p.Hops, p.Reached)
+	if p.Type != TracePacket {
+		return data
+	}
+
+	path := make([]string, len(p.Path))
+	for i, hop := range p.Path {
+		path[i] = fmt.Sprintf("%s@%d", hop.Station, hop.Time.UnixNano())
+	}
+	return data + ":" + strings.Join(path, ",")
+}
+
+// ParseProbe analisa um pacote de ping ou trace
+func ParseProbe(data string) (*Probe, error) {
+	header, body := splitPacket(data)
+	packetType := PacketType(header)
+	if (packetType != EchoPacket && packetType != TracePacket) || body == nil {
+		return nil, fmt.Errorf("não é um pacote de diagnóstico válido")
+	}
+
+	expected := 5
+	if packetType == TracePacket {
+		expected = 6
+	}
+	parts := strings.SplitN(*body, ":", expected)
+	if len(parts) != expected {
+		return nil, fmt.Errorf("formato de pacote inválido: esperado %d partes, obtido %d", expected, len(parts))
+	}
+
+	hops, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("número de saltos inválido: %s", parts[3])
+	}
+	reached, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return nil, fmt.Errorf("saltos até o destino inválidos: %s", parts[4])
+	}
+
+	p := &Probe{
+		Type:        packetType,
+		ID:          parts[0],
+		Origin:      parts[1],
+		Destination: parts[2],
+		Hops:        hops,
+		Reached:     reached,
+	}
+	if packetType == TracePacket && parts[5] != "" {
+		for _, item := range strings.Split(parts[5], ",") {
+			station, stamp, ok := strings.Cut(item, "@")
+			nanos, err := strconv.ParseInt(stamp, 10, 64)
+			if !ok || err != nil {
+				return nil, fmt.Errorf("estação inválida no caminho: %s", item)
+			}
+			p.Path = append(p.Path, Hop{Station: station, Time: time.Unix(0, nanos)})
+		}
+	}
+	return p, nil
+}
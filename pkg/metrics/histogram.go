@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// Limites padrão dos histogramas, em segundos
+var (
+	// TokenRotationBuckets cobre voltas do token em anéis de poucas estações com tempo de token de alguns segundos
+	TokenRotationBuckets = []float64{0.5, 1, 2, 3, 5, 8, 13, 20, 30, 60}
+
+	// LatencyBuckets cobre desde entregas imediatas até mensagens que aguardaram várias voltas do token
+	LatencyBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 20, 30, 60}
+)
+
+// Histogram acumula observações em faixas, no modelo dos histogramas do Prometheus
+type Histogram struct {
+	bounds []float64  // Limites superiores das faixas, em ordem crescente
+	counts []uint64   // Observações em cada faixa (não cumulativas)
+	sum    float64    // Soma das observações
+	count  uint64     // Número de observações
+	mutex  sync.Mutex // Mutex para acesso concorrente
+}
+
+// HistogramSnapshot é uma cópia do histograma em um instante
+// Counts é cumulativo: Counts[i] é o número de observações menores ou iguais a Bounds[i]
+type HistogramSnapshot struct {
+	Bounds []float64
+	Counts []uint64
+	Sum    float64
+	Count  uint64
+}
+
+// NewHistogram cria um histograma com os limites superiores informados
+func NewHistogram(bounds []float64) *Histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)),
+	}
+}
+
+// Observe registra uma observação
+func (h *Histogram) Observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Observações acima do último limite entram apenas na faixa +Inf (count)
+	if i := sort.SearchFloat64s(h.bounds, value); i < len(h.bounds) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+}
+
+// Snapshot retorna uma cópia cumulativa do histograma
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	snapshot := HistogramSnapshot{
+		Bounds: append([]float64(nil), h.bounds...),
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    h.sum,
+		Count:  h.count,
+	}
+	var cumulative uint64
+	for i, count := range h.counts {
+		cumulative += count
+		snapshot.Counts[i] = cumulative
+	}
+	return snapshot
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Writer escreve métricas no formato de texto do Prometheus
+// Cada métrica recebe os rótulos comuns (ex: machine="Alice") além dos próprios
+type Writer struct {
+	w      io.Writer
+	labels map[string]string
+	err    error
+}
+
+// NewWriter cria um Writer com os rótulos comuns a todas as métricas
+func NewWriter(w io.Writer, labels map[string]string) *Writer {
+	return &Writer{w: w, labels: labels}
+}
+
+// Counter escreve um contador
+func (pw *Writer) Counter(name, help string, value float64) {
+	pw.header(name, help, "counter")
+	pw.sample(name, nil, value)
+}
+
+// CounterSeries escreve um contador com uma série por valor do rótulo informado
+func (pw *Writer) CounterSeries(name, help, label string, series map[string]float64) {
+	pw.header(name, help, "counter")
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pw.sample(name, map[string]string{label: key}, series[key])
+	}
+}
+
+// Gauge escreve um medidor
+func (pw *Writer) Gauge(name, help string, value float64) {
+	pw.header(name, help, "gauge")
+	pw.sample(name, nil, value)
+}
+
+// Histogram escreve um histograma, uma série por valor do rótulo informado
+// Com label vazio, series deve conter uma única entrada (a chave é ignorada)
+func (pw *Writer) Histogram(name, help, label string, series map[string]HistogramSnapshot) {
+	pw.header(name, help, "histogram")
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h := series[key]
+		labels := map[string]string{}
+		if label != "" {
+			labels[label] = key
+		}
+		for i, bound := range h.Bounds {
+			pw.sample(name+"_bucket", withLabel(labels, "le", formatFloat(bound)), float64(h.Counts[i]))
+		}
+		pw.sample(name+"_bucket", withLabel(labels, "le", "+Inf"), float64(h.Count))
+		pw.sample(name+"_sum", labels, h.Sum)
+		pw.sample(name+"_count", labels, float64(h.Count))
+	}
+}
+
+// Err retorna o primeiro erro de escrita, se houver
+func (pw *Writer) Err() error {
+	return pw.err
+}
+
+// header escreve as linhas HELP e TYPE da métrica
+func (pw *Writer) header(name, help, kind string) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample escreve uma amostra com os rótulos comuns e os informados
+func (pw *Writer) sample(name string, labels map[string]string, value float64) {
+	all := make(map[string]string, len(pw.labels)+len(labels))
+	for k, v := range pw.labels {
+		all[k] = v
+	}
+	for k, v := range labels {
+		all[k] = v
+	}
+	pw.printf("%s%s %s\n", name, formatLabels(all), formatFloat(value))
+}
+
+// printf escreve no destino, guardando o primeiro erro
+func (pw *Writer) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	_, pw.err = fmt.Fprintf(pw.w, format, args...)
+}
+
+// withLabel retorna uma cópia dos rótulos com mais um rótulo
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	copied := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
+
+// formatLabels formata os rótulos em ordem alfabética: {a="1",b="2"}
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%s", key, strconv.Quote(labels[key]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formata um valor no formato aceito pelo Prometheus
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
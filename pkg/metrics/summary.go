@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultSummaryWindow é o número de observações recentes usadas nos percentis
+const DefaultSummaryWindow = 1000
+
+// Summary guarda as observações mais recentes para calcular mínimo, média, percentis e máximo
+// Ao contrário do histograma, os percentis são exatos, mas apenas sobre a janela de observações
+type Summary struct {
+	window []float64  // Observações recentes (buffer circular)
+	next   int        // Posição da próxima observação na janela
+	count  uint64     // Número de observações desde a criação
+	mutex  sync.Mutex // Mutex para acesso concorrente
+}
+
+// SummarySnapshot resume as observações da janela em um instante
+type SummarySnapshot struct {
+	Count   uint64  `json:"total"`    // Observações desde a criação
+	Samples int     `json:"amostras"` // Observações na janela usada nos cálculos
+	Min     float64 `json:"min"`
+	Avg     float64 `json:"media"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	Max     float64 `json:"max"`
+}
+
+// NewSummary cria um resumo que guarda as últimas size observações
+func NewSummary(size int) *Summary {
+	if size < 1 {
+		size = DefaultSummaryWindow
+	}
+	return &Summary{window: make([]float64, 0, size)}
+}
+
+// Observe registra uma observação, descartando a mais antiga se a janela estiver cheia
+func (s *Summary) Observe(value float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.window) < cap(s.window) {
+		s.window = append(s.window, value)
+	} else {
+		s.window[s.next] = value
+	}
+	s.next = (s.next + 1) % cap(s.window)
+	s.count++
+}
+
+// Snapshot calcula o resumo das observações da janela
+// Sem observações, todos os valores são zero
+func (s *Summary) Snapshot() SummarySnapshot {
+	s.mutex.Lock()
+	sorted := append([]float64(nil), s.window...)
+	count := s.count
+	s.mutex.Unlock()
+
+	snapshot := SummarySnapshot{Count: count, Samples: len(sorted)}
+	if len(sorted) == 0 {
+		return snapshot
+	}
+
+	sort.Float64s(sorted)
+	total := 0.0
+	for _, v := range sorted {
+		total += v
+	}
+	snapshot.Min = sorted[0]
+	snapshot.Max = sorted[len(sorted)-1]
+	snapshot.Avg = total / float64(len(sorted))
+	snapshot.P50 = percentile(sorted, 0.50)
+	snapshot.P95 = percentile(sorted, 0.95)
+	return snapshot
+}
+
+// percentile retorna o percentil p (0 a 1) de valores ordenados, pelo método do posto mais próximo
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// BroadcastReport resume as confirmações de um broadcast que completou o ciclo
+// Indica exatamente quais estações receberam o quadro íntegro
+type BroadcastReport struct {
+	Content   string            // Conteúdo do broadcast
+	Receipts  []message.Receipt // Confirmações registradas por cada estação
+	Delivered []string          // Estações que confirmaram com ACK
+	Failed    []string          // Estações que responderam NAK ou BUSY
+	Completed time.Time         // Momento em que o quadro retornou à origem
+}
+
+// newBroadcastReport monta o relatório a partir do quadro retornado
+func newBroadcastReport(dataMsg *message.DataMessage) *BroadcastReport {
+	report := &BroadcastReport{
+		Content:   dataMsg.Message,
+		Receipts:  dataMsg.Receipts(),
+		Completed: time.Now(),
+	}
+
+	for _, r := range report.Receipts {
+		if r.Status == message.ControlACK {
+			report.Delivered = append(report.Delivered, r.Station)
+		} else {
+			report.Failed = append(report.Failed, r.Station)
+		}
+	}
+
+	return report
+}
+
+// hasErrors verifica se alguma estação detectou erro de CRC (NAK)
+// Recusas por buffer cheio (BUSY) não contam como erro de transmissão
+func (br *BroadcastReport) hasErrors() bool {
+	for _, r := range br.Receipts {
+		if r.Status == message.ControlNAK {
+			return true
+		}
+	}
+	return false
+}
+
+// String retorna uma representação em string do relatório
+func (br *BroadcastReport) String() string {
+	items := make([]string, len(br.Receipts))
+	for i, r := range br.Receipts {
+		items[i] = fmt.Sprintf("%s=%s", r.Station, r.Status)
+	}
+	if len(items) == 0 {
+		return "nenhuma estação recebeu"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,123 @@
+package network
+
+import (
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// EventType identifica o que aconteceu na máquina
+type EventType string
+
+// Tipos de evento publicados pela máquina
+const (
+	EventTokenReceived    EventType = "token_recebido"      // O token chegou a esta máquina
+	EventTokenPassed      EventType = "token_passado"       // O token foi enviado à próxima máquina
+	EventTokenGenerated   EventType = "token_gerado"        // Um token foi gerado (inicial, manual ou pelo watchdog)
+	EventTokenLost        EventType = "token_perdido"       // O watchdog não viu o token no tempo máximo e vai gerar outro
+	EventFrameSent        EventType = "quadro_enviado"      // Um quadro foi transmitido (Frame contém o quadro com o cabeçalho)
+	EventFrameReceived    EventType = "quadro_recebido"     // Um quadro chegou pela rede
+	EventFrameForwarded   EventType = "quadro_repassado"    // Um quadro de dados de outra origem foi repassado
+	EventMessageSent      EventType = "mensagem_enviada"    // Uma mensagem da fila foi transmitida (inclui retransmissões)
+	EventMessageDelivered EventType = "mensagem_entregue"   // Uma mensagem foi aceita por esta máquina
+	EventFrameRefused     EventType = "quadro_recusado"     // Um quadro foi recusado por buffer de recepção cheio (BUSY)
+	EventCRCError         EventType = "erro_crc"            // Um quadro chegou com erro de CRC (NAK)
+	EventACK              EventType = "ack"                 // A mensagem enviada por esta máquina foi confirmada
+	EventNAK              EventType = "nak"                 // A mensagem enviada retornou com erro e será retransmitida
+	EventBusy             EventType = "busy"                // O destino estava ocupado e a mensagem continua na fila
+	EventNotExists        EventType = "maquina_inexistente" // O destino não existe ou está desligado
+)
+
+// Event descreve algo que aconteceu na máquina
+// Os campos não usados pelo tipo do evento ficam vazios
+type Event struct {
+	Type        EventType `json:"tipo"`
+	Time        time.Time `json:"horario"`            // Horário segundo o relógio da máquina
+	Frame       string    `json:"quadro,omitempty"`   // Quadro bruto, nos eventos de quadro
+	Origin      string    `json:"origem,omitempty"`   // Origem da mensagem
+	Destination string    `json:"destino,omitempty"`  // Destino da mensagem
+	Message     string    `json:"mensagem,omitempty"` // Conteúdo da mensagem
+	Detail      string    `json:"detalhe,omitempty"`  // Informação adicional (ex: relatório do broadcast)
+}
+
+// EventHandler recebe os eventos da máquina
+// É chamada no laço de eventos da máquina: não deve bloquear nem chamar métodos
+// da máquina (use Subscribe para isso)
+type EventHandler func(Event)
+
+// subscriber é uma inscrição nos eventos, por função ou por canal
+type subscriber struct {
+	handler EventHandler
+	events  chan Event
+}
+
+// OnEvent registra uma função chamada a cada evento da máquina
+// Retorna a função que cancela a inscrição
+func (m *Machine) OnEvent(handler EventHandler) func() {
+	return m.subscribe(&subscriber{handler: handler})
+}
+
+// Subscribe retorna um canal que recebe os eventos da máquina
+// Se o canal estiver cheio, o evento é descartado para não atrasar a máquina
+// A função retornada cancela a inscrição e fecha o canal
+func (m *Machine) Subscribe(buffer int) (<-chan Event, func()) {
+	events := make(chan Event, buffer)
+	return events, m.subscribe(&subscriber{events: events})
+}
+
+// subscribe adiciona a inscrição e retorna a função que a cancela
+func (m *Machine) subscribe(s *subscriber) func() {
+	m.eventMutex.Lock()
+	defer m.eventMutex.Unlock()
+
+	m.eventCounter++
+	id := m.eventCounter
+	m.subscribers[id] = s
+
+	return func() {
+		m.eventMutex.Lock()
+		defer m.eventMutex.Unlock()
+
+		if _, ok := m.subscribers[id]; !ok {
+			return
+		}
+		delete(m.subscribers, id)
+		if s.events != nil {
+			close(s.events)
+		}
+	}
+}
+
+// emit publica um evento para as inscrições
+// Executado no laço de eventos; as inscrições têm um mutex próprio, pois mudam em outras goroutines
+func (m *Machine) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = m.Now()
+	}
+
+	m.eventMutex.RLock()
+	defer m.eventMutex.RUnlock()
+
+	for _, s := range m.subscribers {
+		if s.handler != nil {
+			s.handler(event)
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+		}
+	}
+}
+
+// emitMessage publica um evento referente a um quadro de dados
+func (m *Machine) emitMessage(eventType EventType, dataMsg *message.DataMessage, detail string) {
+	m.emit(Event{
+		Type:        eventType,
+		Frame:       dataMsg.RawData,
+		Origin:      dataMsg.Origin,
+		Destination: dataMsg.Destination,
+		Message:     dataMsg.Message,
+		Detail:      detail,
+	})
+}
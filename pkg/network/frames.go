@@ -0,0 +1,64 @@
+package network
+
+import (
+	"fmt"
+
+	"ring-network/pkg/message"
+)
+
+// FrameHandler trata um pacote de controle recebido, no formato bruto
+// Pacotes de controle não dependem do token e podem ser enviados a qualquer momento
+// É chamada na goroutine de despacho, fora do laço de eventos, e pode usar os métodos da máquina
+type FrameHandler func(data string)
+
+// RegisterFrameHandler registra a função que trata os pacotes de um tipo (ex: "3000")
+// Permite que outros pacotes (eleição, diagnóstico, etc.) usem o anel sem alterar a máquina
+func (m *Machine) RegisterFrameHandler(packetType string, handler FrameHandler) error {
+	if packetType == message.TokenPacket || packetType == message.DataPacket || packetType == message.SnapshotPacket ||
+		packetType == message.EchoPacket || packetType == message.TracePacket {
+		return fmt.Errorf("tipo de pacote %s é reservado", packetType)
+	}
+
+	var err error
+	m.do(func() {
+		if _, exists := m.frameHandlers[packetType]; exists {
+			err = fmt.Errorf("tipo de pacote %s já possui tratamento registrado", packetType)
+			return
+		}
+		m.frameHandlers[packetType] = handler
+	})
+	return err
+}
+
+// SendFrame envia um pacote de controle para a próxima máquina do anel
+// O envio passa pelo laço de eventos, na ordem dos demais quadros da máquina
+func (m *Machine) SendFrame(data string) error {
+	var err error
+	m.do(func() {
+		err = m.sendPacket(data)
+	})
+	return err
+}
+
+// SendFrameBackward envia um pacote de controle para a máquina anterior do anel
+// Requer o endereço da máquina anterior na configuração (maquina_anterior)
+func (m *Machine) SendFrameBackward(data string) error {
+	if !m.IsBidirectional() {
+		return fmt.Errorf("endereço da máquina anterior não configurado (maquina_anterior)")
+	}
+	var err error
+	m.do(func() {
+		err = m.sendPacketTo(m.config.PrevMachineAddr, data)
+	})
+	return err
+}
+
+// IsBidirectional verifica se a máquina conhece a máquina anterior do anel
+func (m *Machine) IsBidirectional() bool {
+	return m.config.PrevMachineAddr != ""
+}
+
+// Name retorna o nome desta máquina no anel
+func (m *Machine) Name() string {
+	return m.config.MachineName
+}
@@ -0,0 +1,147 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LockStats armazena as métricas da exclusão mútua baseada no token
+type LockStats struct {
+	Held         bool          // Indica se a seção crítica está em posse da aplicação
+	Waiting      bool          // Indica se há um pedido aguardando o token
+	Acquisitions int           // Número de vezes que o token foi obtido para a aplicação
+	Expired      int           // Número de liberações forçadas por tempo máximo excedido
+	TotalWait    time.Duration // Soma dos tempos de espera pelo token
+	MaxWait      time.Duration // Maior tempo de espera pelo token
+	LastWait     time.Duration // Tempo de espera da última aquisição
+	MaxHold      time.Duration // Tempo máximo de posse permitido
+}
+
+// AverageWait retorna o tempo médio de espera pelo token
+func (ls LockStats) AverageWait() time.Duration {
+	if ls.Acquisitions == 0 {
+		return 0
+	}
+	return ls.TotalWait / time.Duration(ls.Acquisitions)
+}
+
+// Acquire bloqueia até que o token chegue a esta máquina e o retém para a aplicação
+// Enquanto a seção crítica estiver em posse da aplicação, o token não circula.
+// A posse é liberada por Release ou automaticamente após o tempo máximo configurado.
+// Retorna erro se o contexto for cancelado antes de o token chegar.
+func (m *Machine) Acquire(ctx context.Context) error {
+	var err error
+	granted := make(chan struct{})
+	m.do(func() {
+		if m.lockHeld || m.lockWaiter != nil {
+			err = fmt.Errorf("seção crítica já solicitada ou em posse da aplicação")
+			return
+		}
+
+		m.lockWaiter = granted
+		m.lockRequested = m.systemNow()
+		m.logf("Aguardando token para seção crítica")
+
+		// Se o token já está aqui e não há mensagem em trânsito, concede imediatamente
+		if m.hasToken && !m.waitingForData {
+			stopTimer(&m.tokenTimer)
+			m.grantLock()
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+		cancelled := false
+		m.do(func() {
+			if m.lockWaiter == granted {
+				m.lockWaiter = nil
+				cancelled = true
+			}
+		})
+		if !cancelled {
+			// O token foi concedido ao mesmo tempo que o cancelamento: devolve-o
+			m.Release()
+		}
+		return ctx.Err()
+	}
+}
+
+// Release libera a seção crítica e devolve o token à circulação
+// A máquina processa sua fila normalmente antes de passar o token adiante
+func (m *Machine) Release() error {
+	var err error
+	m.do(func() {
+		if !m.lockHeld {
+			err = fmt.Errorf("seção crítica não está em posse da aplicação")
+			return
+		}
+		m.releaseLock()
+
+		m.logf("Seção crítica liberada")
+		m.processToken()
+	})
+	return err
+}
+
+// GetLockStats retorna as métricas da exclusão mútua
+func (m *Machine) GetLockStats() LockStats {
+	var stats LockStats
+	m.do(func() {
+		stats = m.lockStats
+		stats.Held = m.lockHeld
+		stats.Waiting = m.lockWaiter != nil
+	})
+	stats.MaxHold = time.Duration(m.config.MaxLockTime) * time.Second
+	return stats
+}
+
+// grantLock entrega o token à aplicação que aguarda em Acquire
+// Executado no laço de eventos, com a posse do token
+func (m *Machine) grantLock() {
+	wait := m.systemNow().Sub(m.lockRequested)
+
+	m.lockHeld = true
+	m.lockStats.Acquisitions++
+	m.lockStats.TotalWait += wait
+	m.lockStats.LastWait = wait
+	if wait > m.lockStats.MaxWait {
+		m.lockStats.MaxWait = wait
+	}
+
+	close(m.lockWaiter)
+	m.lockWaiter = nil
+
+	// Limita o tempo de posse para não bloquear o anel indefinidamente
+	stopTimer(&m.lockTimer)
+	m.lockTimer = m.newTimer(time.Duration(m.config.MaxLockTime)*time.Second, m.onLockTimer)
+
+	m.logf("Token retido para seção crítica (espera: %v)", wait)
+}
+
+// releaseLock encerra a posse da seção crítica
+// Parar o temporizador no laço garante que um disparo antigo não libere uma posse posterior
+// Executado no laço de eventos
+func (m *Machine) releaseLock() {
+	m.lockHeld = false
+	stopTimer(&m.lockTimer)
+}
+
+// expireLock libera a seção crítica quando o tempo máximo de posse é excedido
+// Executado no laço de eventos
+func (m *Machine) expireLock() {
+	if !m.lockHeld {
+		return
+	}
+	m.releaseLock()
+	m.lockStats.Expired++
+
+	m.warnf("Tempo máximo de posse da seção crítica excedido (%ds) - token liberado",
+		m.config.MaxLockTime)
+	m.processToken()
+}
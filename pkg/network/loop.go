@@ -0,0 +1,349 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"ring-network/pkg/capture"
+)
+
+// Laço de eventos
+//
+// Todo o estado da máquina pertence a uma única goroutine, o laço de eventos,
+// iniciada em New. Os quadros recebidos, os temporizadores (posse do token,
+// seção crítica, watchdog) e as chamadas dos métodos públicos chegam a ela por
+// canais e são processados um de cada vez, cada um até o fim. Assim, o estado
+// não precisa de mutex e nenhum temporizador dispara no meio de outro evento.
+//
+// Funções de outros pacotes (tratamento de pacotes de controle, entregas
+// ordenadas) não são chamadas no laço: elas vão para a goroutine de despacho,
+// na ordem em que foram geradas, e podem usar livremente os métodos da máquina.
+
+// command é uma chamada de método público executada no laço de eventos
+type command struct {
+	fn   func()
+	done chan struct{}
+}
+
+// receivedFrame é um quadro lido do transporte, a caminho do laço de eventos
+type receivedFrame struct {
+	data string
+	from string
+}
+
+// do executa fn no laço de eventos e aguarda o término
+// Depois que o laço termina (máquina parada), nenhuma outra goroutine altera o estado
+// e fn é executada na própria goroutine, protegida pelo mutex
+// Com um Scheduler não há laço de eventos: fn é sempre executada assim, seguida das funções de despacho
+// Não deve ser chamada no laço de eventos (nem pelas funções de OnEvent)
+func (m *Machine) do(fn func()) {
+	if m.scheduler != nil {
+		m.mutex.Lock()
+		fn()
+		m.mutex.Unlock()
+		m.runCallbacks()
+		return
+	}
+
+	cmd := command{fn: fn, done: make(chan struct{})}
+	select {
+	case m.commands <- cmd:
+		<-cmd.done
+	case <-m.loopDone:
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		fn()
+	}
+}
+
+// loop é o laço de eventos da máquina
+func (m *Machine) loop() {
+	defer close(m.loopDone)
+
+	for {
+		select {
+		case <-m.done:
+			m.shutdown()
+			return
+
+		case cmd := <-m.commands:
+			cmd.fn()
+			close(cmd.done)
+
+		case frame := <-m.frames:
+			m.handleFrame(frame)
+
+		case <-timerC(m.tokenTimer):
+			m.onTokenTimer()
+
+		case <-timerC(m.lockTimer):
+			m.onLockTimer()
+
+		case <-timerC(m.watchdogTimer):
+			m.onWatchdogTimer()
+
+		case <-timerC(m.startTimer):
+			m.onStartTimer()
+		}
+	}
+}
+
+// onTokenTimer é chamado quando o tempo de posse do token se esgota
+// Executado no laço de eventos
+func (m *Machine) onTokenTimer() {
+	m.tokenTimer = nil
+	m.processToken()
+}
+
+// onLockTimer é chamado quando o tempo máximo de posse da seção crítica se esgota
+// Executado no laço de eventos
+func (m *Machine) onLockTimer() {
+	m.lockTimer = nil
+	m.expireLock()
+}
+
+// onWatchdogTimer é chamado quando o token não é visto pelo tempo máximo de circulação
+// Executado no laço de eventos
+func (m *Machine) onWatchdogTimer() {
+	m.watchdogTimer = nil
+	m.checkToken()
+}
+
+// onStartTimer é chamado quando chega a hora de gerar o token inicial
+// Executado no laço de eventos
+func (m *Machine) onStartTimer() {
+	m.startTimer = nil
+	m.generateInitialToken()
+}
+
+// shutdown para os temporizadores quando a máquina é parada
+// Executado no laço de eventos
+func (m *Machine) shutdown() {
+	m.running = false
+	stopTimer(&m.tokenTimer)
+	stopTimer(&m.lockTimer)
+	stopTimer(&m.watchdogTimer)
+	stopTimer(&m.startTimer)
+
+	m.logf("Máquina parada")
+}
+
+// dispatch agenda fn na goroutine de despacho, sem bloquear o laço de eventos
+func (m *Machine) dispatch(fn func()) {
+	m.callbackMutex.Lock()
+	m.callbacks = append(m.callbacks, fn)
+	m.callbackMutex.Unlock()
+
+	select {
+	case m.callbackNotify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchLoop executa as funções agendadas por dispatch, na ordem
+func (m *Machine) dispatchLoop() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.callbackNotify:
+		}
+
+		m.callbackMutex.Lock()
+		callbacks := m.callbacks
+		m.callbacks = nil
+		m.callbackMutex.Unlock()
+
+		for _, fn := range callbacks {
+			fn()
+		}
+	}
+}
+
+// Start inicia a operação da máquina e bloqueia até ela ser parada com Stop
+// Equivale a Run com um contexto que nunca é cancelado; um erro fatal é apenas registrado no log
+func (m *Machine) Start() {
+	if err := m.Run(context.Background()); err != nil {
+		m.errorf("Máquina encerrada com erro: %v", err)
+	}
+}
+
+// Run executa a máquina até o contexto ser cancelado, Stop ser chamado ou o transporte falhar
+// Retorna nil no encerramento normal e o erro fatal caso contrário
+// Uma máquina parada não pode ser executada novamente
+func (m *Machine) Run(ctx context.Context) error {
+	if m.scheduler != nil {
+		return fmt.Errorf("máquina controlada por um Scheduler: use Begin")
+	}
+	if err := m.begin(); err != nil {
+		return err
+	}
+
+	// Para a máquina quando o contexto é cancelado
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.Stop()
+		case <-m.done:
+		}
+	}()
+
+	return m.receiveLoop()
+}
+
+// begin marca a máquina como em execução e, na máquina responsável, agenda o token inicial
+func (m *Machine) begin() error {
+	select {
+	case <-m.done:
+		return fmt.Errorf("máquina já foi parada")
+	default:
+	}
+
+	var err error
+	m.do(func() {
+		if m.running {
+			err = fmt.Errorf("máquina já está em execução")
+			return
+		}
+		m.running = true
+		m.logf("Máquina iniciada na porta %d", m.config.ListenPort)
+
+		// A máquina responsável gera o token inicial após um pequeno atraso
+		// e passa a vigiar a circulação do token
+		if m.config.GeneratesToken {
+			m.startTimer = m.newTimer(1*time.Second, m.onStartTimer)
+			m.resetWatchdog()
+		}
+	})
+	return err
+}
+
+// receiveLoop lê os quadros do transporte e os entrega ao laço de eventos
+// Retorna quando a máquina é parada ou quando o transporte é fechado por outro motivo
+func (m *Machine) receiveLoop() error {
+	// O buffer comporta o maior datagrama UDP, pois o marcador de snapshot acumula o estado das estações
+	buffer := make([]byte, 65535)
+	for {
+		// Define um timeout para não bloquear indefinidamente
+		n, addr, err := m.transport.ReadFrom(buffer, time.Now().Add(1*time.Second))
+		if err != nil {
+			select {
+			case <-m.done:
+				return nil
+			default:
+			}
+			// Ignora erros de timeout
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				continue
+			}
+			// Transporte fechado sem Stop: a máquina não consegue mais receber
+			if errors.Is(err, net.ErrClosed) {
+				m.Stop()
+				return fmt.Errorf("transporte encerrado: %v", err)
+			}
+			m.errorf("Erro ao ler dados: %v", err)
+			continue
+		}
+
+		if !m.Deliver(buffer[:n], addr) {
+			return nil
+		}
+	}
+}
+
+// Deliver entrega à máquina um quadro recebido de from
+// Usado pela recepção do transporte e por quem controla a máquina com um Scheduler (ex: um simulador)
+// Retorna false se a máquina já foi parada
+func (m *Machine) Deliver(data []byte, from string) bool {
+	select {
+	case <-m.done:
+		return false
+	default:
+	}
+
+	m.capturePacket(m.systemNow(), capture.Received, from, string(data))
+	frame := receivedFrame{data: string(data), from: from}
+
+	if m.scheduler != nil {
+		m.do(func() { m.handleFrame(frame) })
+		return true
+	}
+	select {
+	case m.frames <- frame:
+		return true
+	case <-m.done:
+		return false
+	}
+}
+
+// capturePacket grava o quadro na captura, se habilitada
+// Chamado no laço de eventos (envio) e na goroutine de recepção; a captura tem mutex próprio
+func (m *Machine) capturePacket(t time.Time, direction capture.Direction, peer, data string) {
+	if m.capture == nil {
+		return
+	}
+	if err := m.capture.WritePacket(t, direction, peer, []byte(data)); err != nil {
+		m.errorf("Captura: %v", err)
+	}
+}
+
+// Stop encerra a operação da máquina e aguarda o fim do laço de eventos
+// Fecha o transporte e para os temporizadores; chamadas repetidas não têm efeito
+// Não deve ser chamada no laço de eventos (nem pelas funções de OnEvent)
+func (m *Machine) Stop() {
+	m.stopOnce.Do(func() {
+		// Com um Scheduler não há laço de eventos para parar os temporizadores
+		if m.scheduler != nil {
+			m.do(m.shutdown)
+		}
+		close(m.done)
+		m.transport.Close()
+	})
+	<-m.loopDone
+}
+
+// Done retorna um canal fechado quando a máquina é parada
+func (m *Machine) Done() <-chan struct{} {
+	return m.done
+}
+
+// watchdogTimeout é o tempo máximo esperado para o token dar uma volta no anel
+// Considera o tempo do token multiplicado pelo número estimado de máquinas
+// e adiciona uma margem de segurança, além do tempo máximo de seção crítica:
+// outra estação pode reter o token por até tempo_max_lock sem que ele tenha se perdido
+func (m *Machine) watchdogTimeout() time.Duration {
+	return m.tokenTime*3*2 + 3*time.Second + time.Duration(m.config.MaxLockTime)*time.Second
+}
+
+// resetWatchdog reinicia a contagem do watchdog a partir de agora (o token acabou de ser visto)
+// Apenas a máquina autorizada a gerar tokens vigia a circulação
+// Executado no laço de eventos
+func (m *Machine) resetWatchdog() {
+	if !m.config.GeneratesToken || !m.running {
+		return
+	}
+	m.lastTokenSeen = m.systemNow()
+	stopTimer(&m.watchdogTimer)
+	m.watchdogTimer = m.newTimer(m.watchdogTimeout(), m.onWatchdogTimer)
+}
+
+// checkToken é chamado quando o token não é visto pelo tempo máximo de circulação
+// Se esta máquina não o possui, assume que o token foi perdido e gera um novo
+// Implementa um mecanismo de recuperação de falhas na rede
+// Executado no laço de eventos
+func (m *Machine) checkToken() {
+	if m.hasToken {
+		// O token está retido aqui (ex: seção crítica), não foi perdido
+		m.resetWatchdog()
+		return
+	}
+
+	timeSinceLastToken := m.systemNow().Sub(m.lastTokenSeen)
+	m.warnf("Token perdido! (último visto há %v) Gerando novo token...", timeSinceLastToken)
+	m.emit(Event{Type: EventTokenLost, Detail: fmt.Sprintf("último visto há %v", timeSinceLastToken)})
+	m.generateInitialToken()
+}
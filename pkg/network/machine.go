@@ -1,15 +1,22 @@
 package network
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"net"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"ring-network/internal/queue"
+	"ring-network/pkg/capture"
+	"ring-network/pkg/clock"
 	"ring-network/pkg/config"
 	"ring-network/pkg/message"
+	"ring-network/pkg/metrics"
 )
 
 // MachineStatus armazena informações sobre o estado atual da máquina
@@ -24,141 +31,271 @@ type MachineStatus struct {
 	MessagesReceived int       // Número de mensagens recebidas
 	ErrorsDetected   int       // Número de erros de CRC detectados
 	TokensGenerated  int       // Número de tokens gerados por esta máquina
+	InboxSize        int       // Número de mensagens no buffer de recepção
+	BusyReplies      int       // Número de quadros recusados por buffer de recepção cheio
+	BusyReceived     int       // Número de respostas BUSY recebidas de destinos ocupados
+	MessagesDeferred int       // Número de mensagens guardadas por uma caixa postal
+	MailboxSize      int       // Número de mensagens guardadas nesta caixa postal
+	Retransmissions  int       // Número de retransmissões por erro (NAK)
 }
 
 // Machine representa uma máquina na rede em anel
 // Implementa a lógica de processamento de mensagens e token
 type Machine struct {
-	config           *config.Config       // Configuração da máquina
-	conn             *net.UDPConn         // Conexão UDP para comunicação
-	queue            *queue.MessageQueue  // Fila de mensagens para envio
-	hasToken         bool                 // Indica se possui o token
-	running          bool                 // Indica se a máquina está em execução
-	mutex            sync.RWMutex         // Mutex para acesso concorrente
-	lastActivity     time.Time            // Timestamp da última atividade
-	status           *MachineStatus       // Status atual da máquina
-	tokenTimeout     *time.Timer          // Timer para processamento do token
-	waitingForData   bool                 // Indica se está aguardando resposta
-	currentDataMsg   *message.DataMessage // Mensagem atual sendo processada
-	errorProbability float64              // Probabilidade de introduzir erro
+	config           *config.Config                // Configuração da máquina
+	transport        Transport                     // Meio de troca de quadros (UDP por padrão)
+	logger           *slog.Logger                  // Destino das linhas de log
+	capture          capture.Recorder              // Captura dos quadros (nil = desabilitada)
+	rng              *rand.Rand                    // Gerador usado para introduzir erros (acesso no laço de eventos)
+	queue            *queue.MessageQueue           // Fila de mensagens para envio
+	inbox            *queue.Inbox                  // Buffer de recepção de mensagens entregues
+	hasToken         bool                          // Indica se possui o token
+	running          bool                          // Indica se a máquina está em execução
+	mutex            sync.Mutex                    // Protege o estado apenas depois que o laço de eventos termina (ver do)
+	lastActivity     time.Time                     // Timestamp da última atividade
+	status           *MachineStatus                // Status atual da máquina
+	tokenTimer       *timer                        // Temporizador do tempo de posse do token
+	tokenTime        time.Duration                 // Tempo de posse do token antes de transmitir ou passá-lo
+	waitingForData   bool                          // Indica se está aguardando resposta
+	currentDataMsg   *message.DataMessage          // Mensagem atual sendo processada
+	lastBroadcast    *BroadcastReport              // Relatório do último broadcast enviado
+	groups           map[string]bool               // Grupos multicast dos quais participa
+	mailbox          *Mailbox                      // Caixa postal (nil se a máquina não tem esse papel)
+	currentRelay     *MailboxEntry                 // Mensagem da caixa postal sendo entregue
+	lockWaiter       chan struct{}                 // Pedido da aplicação aguardando o token (Acquire)
+	lockRequested    time.Time                     // Momento do pedido de seção crítica
+	lockHeld         bool                          // Indica se o token está retido pela aplicação
+	lockTimer        *timer                        // Temporizador do tempo máximo de posse da seção crítica
+	lockStats        LockStats                     // Métricas da exclusão mútua
+	orderSeq         int                           // Maior número de sequência conhecido (ordem total)
+	nextDeliver      int                           // Próximo número de sequência a entregar (0 = ainda não definido)
+	missedSeq        int                           // Menor número recusado (NAK) antes de definir nextDeliver
+	holdback         map[int]OrderedMessage        // Mensagens ordenadas retidas aguardando lacunas
+	orderedHandlers  []OrderedHandler              // Funções chamadas a cada entrega ordenada
+	orderedDelivered int                           // Número de mensagens ordenadas entregues
+	done             chan struct{}                 // Fechado quando a máquina é parada
+	stopOnce         sync.Once                     // Garante que a parada ocorra uma única vez
+	commands         chan command                  // Chamadas dos métodos públicos para o laço de eventos
+	frames           chan receivedFrame            // Quadros recebidos para o laço de eventos
+	loopDone         chan struct{}                 // Fechado quando o laço de eventos termina
+	callbacks        []func()                      // Funções aguardando a goroutine de despacho
+	callbackMutex    sync.Mutex                    // Mutex das funções aguardando despacho
+	callbackNotify   chan struct{}                 // Sinaliza a goroutine de despacho
+	watchdogTimer    *timer                        // Temporizador do watchdog do token
+	startTimer       *timer                        // Temporizador da geração do token inicial
+	scheduler        Scheduler                     // Horário e temporizadores fora do tempo real (nil = laço de eventos)
+	inCallbacks      bool                          // Indica se as funções de despacho estão em execução (com Scheduler)
+	lastTokenSeen    time.Time                     // Última vez que o token passou por esta máquina (watchdog)
+	frameHandlers    map[string]FrameHandler       // Funções que tratam outros tipos de pacote
+	snapshots        map[string]*snapshotState     // Snapshots iniciados por esta máquina em andamento
+	snapshotCounter  int                           // Contador para identificar os snapshots
+	probes           map[string]*probeState        // Pacotes de ping e trace enviados aguardando retorno
+	probeCounter     int                           // Contador para identificar os pacotes de ping e trace
+	clock            *clock.Logical                // Relógio lógico (Lamport e, opcionalmente, vetorial)
+	wallClock        *clock.Physical               // Horário da máquina, corrigido pela sincronização de relógios
+	lastTokenArrival time.Time                     // Chegada anterior do token, para medir o tempo de volta
+	tokenRotation    *metrics.Histogram            // Tempo entre chegadas consecutivas do token
+	deliveryLatency  map[string]*metrics.Histogram // Tempo do enfileiramento à confirmação, por destino
+	queueDelay       *metrics.Summary              // Tempo do enfileiramento à primeira transmissão
+	ackDelay         *metrics.Summary              // Tempo da primeira transmissão à confirmação
+	deliveryRetries  *metrics.Summary              // Retransmissões por mensagem entregue
+	rotationStats    *metrics.Summary              // Tempo entre chegadas consecutivas do token (percentis)
+	subscribers      map[int]*subscriber           // Inscrições nos eventos da máquina
+	eventCounter     int                           // Contador para identificar as inscrições
+	eventMutex       sync.RWMutex                  // Mutex das inscrições nos eventos
+	errorProbability float64                       // Probabilidade de introduzir erro
 }
 
 // NewMachine cria uma nova instância de máquina com a configuração fornecida
-// Inicializa a conexão UDP e as estruturas internas
+// Equivale a New(WithConfig(cfg)), com as demais opções no padrão
 func NewMachine(cfg *config.Config) (*Machine, error) {
+	return New(WithConfig(cfg))
+}
+
+// New cria uma máquina com as opções informadas
+// A configuração é obrigatória (WithConfig); sem WithTransport, abre o socket UDP na porta de escuta
+func New(opts ...Option) (*Machine, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg := o.config
+	if cfg == nil {
+		return nil, fmt.Errorf("configuração não informada (WithConfig)")
+	}
+
 	// Valida a configuração antes de prosseguir
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("configuração inválida: %v", err)
 	}
+	if o.queueCapacity < 1 {
+		return nil, fmt.Errorf("capacidade da fila deve ser positiva: %d", o.queueCapacity)
+	}
+	if o.rng == nil {
+		return nil, fmt.Errorf("gerador de números aleatórios não informado (WithRand)")
+	}
+	if !(o.errorProbability >= 0 && o.errorProbability <= 1) {
+		return nil, fmt.Errorf("probabilidade de erro deve estar entre 0 e 1: %v", o.errorProbability)
+	}
 
-	// Configura o endereço UDP para escuta
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", cfg.ListenPort))
-	if err != nil {
-		return nil, fmt.Errorf("erro ao resolver endereço: %v", err)
+	// Sem transporte informado, usa UDP na porta de escuta da configuração
+	transport := o.transport
+	if transport == nil {
+		udp, err := NewUDPTransport(cfg.ListenPort)
+		if err != nil {
+			return nil, err
+		}
+		transport = udp
 	}
 
-	// Cria o socket UDP para comunicação
-	conn, err := net.ListenUDP("udp", addr)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criar socket UDP: %v", err)
+	logger := o.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	wallClock := o.wallClock
+	if wallClock == nil {
+		skew := time.Duration(cfg.ClockSkew) * time.Millisecond
+		wallClock = clock.NewPhysical(skew)
+		if o.scheduler != nil {
+			wallClock = clock.NewPhysicalFrom(o.scheduler.Now, skew)
+		}
+	}
+
+	// O tempo de posse da configuração é em segundos; WithTokenTime permite frações (ou zero)
+	tokenTime := time.Duration(cfg.TokenTime) * time.Second
+	if o.tokenTime >= 0 {
+		tokenTime = o.tokenTime
+	}
+
+	now := time.Now()
+	if o.scheduler != nil {
+		now = o.scheduler.Now()
 	}
 
 	// Inicializa a máquina com valores padrão
 	machine := &Machine{
 		config:           cfg,
-		conn:             conn,
-		queue:            queue.NewMessageQueue(10), // Fila com capacidade para 10 mensagens
+		transport:        transport,
+		logger:           logger,
+		capture:          o.capture,
+		scheduler:        o.scheduler,
+		tokenTime:        tokenTime,
+		rng:              o.rng,
+		queue:            queue.NewMessageQueue(o.queueCapacity),
+		inbox:            queue.NewInbox(cfg.ReceiveBufferSize),
+		groups:           make(map[string]bool),
+		holdback:         make(map[int]OrderedMessage),
+		done:             make(chan struct{}),
+		commands:         make(chan command),
+		frames:           make(chan receivedFrame, 64),
+		loopDone:         make(chan struct{}),
+		callbackNotify:   make(chan struct{}, 1),
+		frameHandlers:    make(map[string]FrameHandler),
+		snapshots:        make(map[string]*snapshotState),
+		probes:           make(map[string]*probeState),
+		clock:            clock.NewLogical(cfg.MachineName, cfg.VectorClock),
+		wallClock:        wallClock,
+		tokenRotation:    metrics.NewHistogram(metrics.TokenRotationBuckets),
+		deliveryLatency:  make(map[string]*metrics.Histogram),
+		queueDelay:       metrics.NewSummary(metrics.DefaultSummaryWindow),
+		ackDelay:         metrics.NewSummary(metrics.DefaultSummaryWindow),
+		deliveryRetries:  metrics.NewSummary(metrics.DefaultSummaryWindow),
+		rotationStats:    metrics.NewSummary(metrics.DefaultSummaryWindow),
+		subscribers:      make(map[int]*subscriber),
 		hasToken:         false,
 		running:          false,
-		lastActivity:     time.Now(),
+		lastActivity:     now,
 		waitingForData:   false,
-		errorProbability: 0.1, // 10% de chance de introduzir erro
+		errorProbability: o.errorProbability,
 		status: &MachineStatus{
 			MachineName:  cfg.MachineName,
 			HasToken:     false,
-			LastActivity: time.Now(),
+			LastActivity: now,
 		},
 	}
 
-	return machine, nil
-}
-
-// Start inicia a operação da máquina
-// Executa o loop principal de recebimento de pacotes
-func (m *Machine) Start() {
-	m.mutex.Lock()
-	m.running = true
-	m.mutex.Unlock()
-
-	log.Printf("[%s] Máquina iniciada na porta %d", m.config.MachineName, m.config.ListenPort)
-
-	// Se esta máquina é responsável por gerar o token inicial
-	if m.config.GeneratesToken {
-		// Gera o token inicial após um pequeno delay
-		go func() {
-			time.Sleep(1 * time.Second)
-			m.generateInitialToken()
-		}()
-
-		// Inicia o watchdog para monitorar a circulação do token
-		go m.tokenWatchdog()
+	// Entra nos grupos multicast definidos na configuração
+	for _, group := range cfg.Groups {
+		machine.groups[message.GroupAddress(group)] = true
 	}
 
-	// Loop principal de recebimento de pacotes
-	buffer := make([]byte, 1024)
-	for m.isRunning() {
-		// Define um timeout para não bloquear indefinidamente
-		m.conn.SetReadDeadline(time.Now().Add(1 * time.Second))
-		n, addr, err := m.conn.ReadFromUDP(buffer)
+	// Carrega a caixa postal, se a máquina tiver esse papel
+	if cfg.Mailbox {
+		mailbox, err := NewMailbox(cfg.MailboxFile)
 		if err != nil {
-			// Ignora erros de timeout
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				continue
-			}
-			log.Printf("[%s] Erro ao ler dados: %v", m.config.MachineName, err)
-			continue
+			transport.Close()
+			return nil, err
 		}
+		machine.mailbox = mailbox
+	}
 
-		// Processa os dados recebidos
-		data := string(buffer[:n])
-		log.Printf("[%s] Recebido de %s: %s", m.config.MachineName, addr, data)
+	// Os horários da fila medem a espera e o tempo até o ACK: seguem o relógio do sistema, que não
+	// salta com o desvio configurado nem com as correções da sincronização
+	// Os horários do buffer de recepção seguem o relógio corrigido da máquina
+	machine.queue.SetClock(machine.systemNow)
+	machine.inbox.SetClock(machine.Now)
 
-		m.handleReceivedData(data)
+	// Com um Scheduler, os métodos públicos são executados por quem chama (ver Begin)
+	if machine.scheduler != nil {
+		close(machine.loopDone)
+		return machine, nil
 	}
-}
 
-// Stop encerra a operação da máquina
-// Fecha conexões e para timers
-func (m *Machine) Stop() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	// O laço de eventos atende os métodos públicos desde já; Run passa a receber os quadros
+	go machine.loop()
+	go machine.dispatchLoop()
 
-	m.running = false
-	if m.conn != nil {
-		m.conn.Close()
-	}
-	if m.tokenTimeout != nil {
-		m.tokenTimeout.Stop()
-	}
+	return machine, nil
+}
 
-	log.Printf("[%s] Máquina parada", m.config.MachineName)
+// handleFrame processa um quadro recebido pela rede
+// Executado no laço de eventos
+func (m *Machine) handleFrame(frame receivedFrame) {
+	m.observeClock(frame.data)
+	m.debugf("Recebido de %s: %s", frame.from, frame.data)
+	m.emit(Event{Type: EventFrameReceived, Frame: frame.data})
+	m.handleReceivedData(frame.data)
 }
 
 // handleReceivedData processa os dados recebidos pela rede
 // Identifica se é um token ou pacote de dados e encaminha para o handler apropriado
 func (m *Machine) handleReceivedData(data string) {
-	m.updateLastActivity()
+	m.lastActivity = m.systemNow()
+
+	// O marcador de snapshot é tratado antes da gravação do canal de entrada
+	if message.PacketType(data) == message.SnapshotPacket {
+		m.handleMarker(data)
+		return
+	}
+	m.recordChannel(data)
 
 	// Verifica se é um pacote de token
 	if message.IsTokenPacket(data) {
-		m.handleToken()
+		m.handleToken(data)
 		return
 	}
 
+	// Pacotes de ping e trace são tratados pela própria máquina
+	if message.IsProbePacket(data) {
+		m.handleProbe(data)
+		return
+	}
+
+	// Pacotes de controle de outros tipos são entregues à função registrada,
+	// na goroutine de despacho, para que ela possa usar os métodos da máquina
+	if packetType := message.PacketType(data); packetType != message.DataPacket {
+		if handler := m.frameHandlers[packetType]; handler != nil {
+			m.dispatch(func() { handler(data) })
+			return
+		}
+	}
+
 	// Se não for token, tenta parsear como pacote de dados
 	dataMsg, err := message.ParseDataPacket(data)
 	if err != nil {
-		log.Printf("[%s] Erro ao parsear pacote de dados: %v", m.config.MachineName, err)
+		m.errorf("Erro ao parsear pacote de dados: %v", err)
 		return
 	}
 
@@ -167,34 +304,39 @@ func (m *Machine) handleReceivedData(data string) {
 
 // handleToken processa o recebimento de um token
 // Atualiza o estado da máquina e agenda o processamento do token
-func (m *Machine) handleToken() {
-	log.Printf("[%s] Token recebido", m.config.MachineName)
+func (m *Machine) handleToken(data string) {
+	m.debugf("Token recebido")
+	m.emit(Event{Type: EventTokenReceived})
 
-	m.mutex.Lock()
+	m.observeTokenSeq(message.TokenSeq(data))
 	m.hasToken = true
 	m.status.HasToken = true
 	m.status.TokensProcessed++
-	m.mutex.Unlock()
+	if !m.lastTokenArrival.IsZero() {
+		rotation := m.systemNow().Sub(m.lastTokenArrival).Seconds()
+		m.tokenRotation.Observe(rotation)
+		m.rotationStats.Observe(rotation)
+	}
+	m.lastTokenArrival = m.systemNow()
+	m.resetWatchdog()
 
-	// Cancela qualquer timer de token anterior
-	if m.tokenTimeout != nil {
-		m.tokenTimeout.Stop()
+	// Se a aplicação aguarda a seção crítica, retém o token em vez de agendar o processamento
+	if m.lockWaiter != nil {
+		m.grantLock()
+		return
 	}
 
-	// Agenda o processamento do token após o tempo configurado
-	m.tokenTimeout = time.AfterFunc(time.Duration(m.config.TokenTime)*time.Second, func() {
-		m.processToken()
-	})
+	// Agenda o processamento do token após o tempo configurado, cancelando um agendamento anterior
+	stopTimer(&m.tokenTimer)
+	m.tokenTimer = m.newTimer(m.tokenTime, m.onTokenTimer)
 }
 
 // processToken é chamado quando o tempo de posse do token expira
 // Verifica se há mensagens na fila para enviar ou passa o token adiante
+// Executado no laço de eventos
 func (m *Machine) processToken() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	// Verifica se ainda possui o token
-	if !m.hasToken {
+	// Verifica se ainda possui o token e se ele não está retido pela aplicação
+	if !m.hasToken || m.lockHeld {
 		return
 	}
 
@@ -205,29 +347,50 @@ func (m *Machine) processToken() {
 			// Cria um pacote de dados com a mensagem da fila
 			dataMsg := message.CreateDataPacket(m.config.MachineName, queuedMsg.Destination, queuedMsg.Content)
 
-			// Tratamento especial para mensagens broadcast
-			if queuedMsg.Destination == "TODOS" {
-				log.Printf("[%s] Enviando mensagem BROADCAST: %s", m.config.MachineName, queuedMsg.Content)
-			} else {
-				// Introduz erro com probabilidade configurada (exceto para broadcast)
-				if dataMsg.IntroduceError(m.errorProbability) {
-					log.Printf("[%s] Erro introduzido na mensagem para %s", m.config.MachineName, queuedMsg.Destination)
+			// Difusão com ordem total: o portador do token atribui o próximo número de sequência
+			// (retransmissões mantêm o número já atribuído) e entrega a própria mensagem na ordem
+			if queuedMsg.Ordered {
+				if queuedMsg.Seq == 0 {
+					seq := m.assignSeq()
+					m.queue.SetFirstMessageSeq(seq)
+					m.holdbackOrdered(seq, m.config.MachineName, queuedMsg.Content)
 				}
+				dataMsg = message.CreateOrderedPacket(m.config.MachineName, queuedMsg.Content, queuedMsg.Seq)
+			}
+
+			// Tratamento especial para mensagens broadcast e multicast
+			if dataMsg.HasReceipts() {
+				m.logFrame(slog.LevelInfo, dataMsg, "Enviando mensagem para %s: %s", queuedMsg.Destination, queuedMsg.Content)
+
+				// Em uma retransmissão, as estações que já confirmaram não recebem novamente
+				for _, station := range queuedMsg.Delivered {
+					dataMsg.SetReceipt(station, message.ControlACK)
+				}
+			}
+
+			// Introduz erro com probabilidade configurada
+			if dataMsg.IntroduceError(m.rng, m.errorProbability) {
+				m.logFrame(slog.LevelWarn, dataMsg, "Erro introduzido na mensagem para %s", queuedMsg.Destination)
 			}
 
 			// Marca que está aguardando resposta para esta mensagem
+			m.observeTransmission(queuedMsg)
 			m.waitingForData = true
 			m.currentDataMsg = dataMsg
 
 			// Envia o pacote e atualiza estatísticas
 			m.sendPacket(dataMsg.RawData)
 			m.status.MessagesSent++
+			m.emitMessage(EventMessageSent, dataMsg, "")
 
-			log.Printf("[%s] Mensagem enviada para %s: %s", m.config.MachineName, queuedMsg.Destination, queuedMsg.Content)
+			m.logFrame(slog.LevelInfo, dataMsg, "Mensagem enviada para %s: %s", queuedMsg.Destination, queuedMsg.Content)
 		}
+	} else if m.mailbox != nil && m.mailbox.Size() > 0 {
+		// Caixa postal: aproveita o token para tentar entregar uma mensagem guardada
+		m.relayMailboxEntry()
 	} else {
 		// Se não há mensagens, passa o token adiante
-		log.Printf("[%s] Fila vazia, passando token", m.config.MachineName)
+		m.debugf("Fila vazia, passando token")
 		m.passToken()
 	}
 }
@@ -236,53 +399,67 @@ func (m *Machine) processToken() {
 // Determina se a mensagem é para esta máquina, se é uma mensagem retornada,
 // ou se deve ser encaminhada
 func (m *Machine) handleDataPacket(dataMsg *message.DataMessage) {
-	log.Printf("[%s] Pacote de dados recebido: %s", m.config.MachineName, dataMsg.String())
+	m.logFrame(slog.LevelDebug, dataMsg, "Pacote de dados recebido: %s", dataMsg.String())
+
+	// Mensagens reenviadas pela caixa postal retornam à caixa postal, e não à origem original
+	if message.IsRelayControl(dataMsg.Control) && dataMsg.Destination != m.config.MachineName {
+		m.handleRelayedMessage(dataMsg)
+		return
+	}
 
-	// Verifica se a mensagem é para esta máquina ou é broadcast
-	if dataMsg.Destination == m.config.MachineName || dataMsg.Destination == "TODOS" {
+	// Verifica se a mensagem é para esta máquina, broadcast ou para um grupo do qual participa
+	// (um broadcast/multicast originado aqui que completou o ciclo é tratado como retorno)
+	if dataMsg.HasReceipts() && dataMsg.Origin == m.config.MachineName {
+		m.handleReturnedMessage(dataMsg)
+	} else if dataMsg.Destination == m.config.MachineName || dataMsg.IsBroadcast() {
 		m.handleMessageForThisMachine(dataMsg)
+	} else if dataMsg.IsMulticast() && m.groups[message.GroupAddress(dataMsg.Destination)] {
+		m.handleBroadcastForThisMachine(dataMsg)
 	} else if dataMsg.Origin == m.config.MachineName {
 		m.handleReturnedMessage(dataMsg)
+	} else if dataMsg.Control == message.ControlStore && m.mailbox != nil {
+		m.storeInMailbox(dataMsg)
 	} else {
 		m.forwardMessage(dataMsg)
 	}
 }
 
 // handleMessageForThisMachine processa uma mensagem destinada a esta máquina
-// Verifica a integridade usando CRC e envia ACK/NAK apropriado
+// Verifica a integridade usando CRC e envia ACK/NAK apropriado,
+// ou BUSY se o buffer de recepção estiver cheio
 func (m *Machine) handleMessageForThisMachine(dataMsg *message.DataMessage) {
-	m.status.MessagesReceived++
-
 	// Tratamento especial para mensagens broadcast
-	if dataMsg.Destination == "TODOS" {
-		log.Printf("[%s] Mensagem BROADCAST recebida de %s: %s", m.config.MachineName, dataMsg.Origin, dataMsg.Message)
-
-		// Se a origem do broadcast é esta própria máquina, significa que completou o ciclo
-		if dataMsg.Origin == m.config.MachineName {
-			m.mutex.Lock()
-			m.waitingForData = false
-			m.currentDataMsg = nil
-			m.queue.RemoveFirstMessage()
-			m.passToken()
-			m.mutex.Unlock()
-			return
-		}
-
-		// Encaminha o broadcast para a próxima máquina
-		m.forwardMessage(dataMsg)
+	if dataMsg.IsBroadcast() {
+		m.handleBroadcastForThisMachine(dataMsg)
 		return
 	}
 
 	// Para mensagens unicast, verifica a integridade usando CRC
-	if dataMsg.VerifyIntegrity() {
-		log.Printf("[%s] Mensagem recebida de %s: %s", m.config.MachineName, dataMsg.Origin, dataMsg.Message)
-		dataMsg.SetControl(message.ControlACK) // Envia ACK se íntegra
-	} else {
-		log.Printf("[%s] Erro detectado na mensagem de %s", m.config.MachineName, dataMsg.Origin)
-		dataMsg.SetControl(message.ControlNAK) // Envia NAK se corrompida
+	var reply string
+	if !dataMsg.VerifyIntegrity() {
+		m.logFrame(slog.LevelWarn, dataMsg, "Erro detectado na mensagem de %s", dataMsg.Origin)
+		m.emitMessage(EventCRCError, dataMsg, "")
+		reply = message.ControlNAK // Envia NAK se corrompida
 		m.status.ErrorsDetected++
+	} else if err := m.inbox.Add(dataMsg.Origin, dataMsg.Message); err != nil {
+		// Buffer de recepção cheio: recusa o quadro para que a origem tente novamente
+		m.logFrame(slog.LevelWarn, dataMsg, "Receptor ocupado, recusando mensagem de %s: %v", dataMsg.Origin, err)
+		m.emitMessage(EventFrameRefused, dataMsg, err.Error())
+		reply = message.ControlBusy
+		m.status.BusyReplies++
+	} else {
+		m.logFrame(slog.LevelInfo, dataMsg, "Mensagem recebida de %s: %s", dataMsg.Origin, dataMsg.Message)
+		m.emitMessage(EventMessageDelivered, dataMsg, "")
+		reply = message.ControlACK // Envia ACK se íntegra
+		m.status.MessagesReceived++
 	}
 
+	// Mensagens entregues pela caixa postal mantêm o prefixo para retornar a ela
+	if message.IsRelayControl(dataMsg.Control) {
+		reply = message.RelayControl(reply)
+	}
+	dataMsg.SetControl(reply)
+
 	// Envia a resposta (ACK/NAK) de volta para a origem
 	m.sendPacket(dataMsg.RawData)
 }
@@ -290,22 +467,15 @@ func (m *Machine) handleMessageForThisMachine(dataMsg *message.DataMessage) {
 // handleReturnedMessage processa uma mensagem que retornou à sua origem
 // Analisa o campo de controle (ACK/NAK) e toma a ação apropriada
 func (m *Machine) handleReturnedMessage(dataMsg *message.DataMessage) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	// Caso especial para broadcast que completou o ciclo
-	if dataMsg.Destination == "TODOS" {
-		log.Printf("[%s] Mensagem BROADCAST completou o ciclo", m.config.MachineName)
-		m.queue.RemoveFirstMessage()
-		m.waitingForData = false
-		m.currentDataMsg = nil
-		m.passToken()
+	// Caso especial para broadcast/multicast que completou o ciclo
+	if dataMsg.HasReceipts() {
+		m.handleReturnedBroadcast(dataMsg)
 		return
 	}
 
 	// Verifica se estava esperando resposta para alguma mensagem
 	if !m.waitingForData || m.currentDataMsg == nil {
-		log.Printf("[%s] Mensagem retornada inesperada", m.config.MachineName)
+		m.logFrame(slog.LevelWarn, dataMsg, "Mensagem retornada inesperada")
 		return
 	}
 
@@ -317,29 +487,167 @@ func (m *Machine) handleReturnedMessage(dataMsg *message.DataMessage) {
 	switch dataMsg.Control {
 	case message.ControlACK:
 		// Mensagem recebida com sucesso, remove da fila
-		log.Printf("[%s] ACK recebido para mensagem para %s", m.config.MachineName, dataMsg.Destination)
+		m.logFrame(slog.LevelInfo, dataMsg, "ACK recebido para mensagem para %s", dataMsg.Destination)
+		m.emitMessage(EventACK, dataMsg, "")
+		m.observeDelivery()
 		m.queue.RemoveFirstMessage()
 
 	case message.ControlNAK:
 		// Erro detectado, incrementa contador de tentativas para retransmissão
-		log.Printf("[%s] NAK recebido para mensagem para %s - será retransmitida", m.config.MachineName, dataMsg.Destination)
+		m.logFrame(slog.LevelWarn, dataMsg, "NAK recebido para mensagem para %s - será retransmitida", dataMsg.Destination)
+		m.emitMessage(EventNAK, dataMsg, "")
 		m.queue.IncrementRetries()
+		m.status.Retransmissions++
+
+	case message.ControlBusy:
+		// Destino ocupado: mantém a mensagem na fila sem contar como erro de transmissão
+		m.logFrame(slog.LevelWarn, dataMsg, "Destino %s ocupado (BUSY) - mensagem mantida na fila", dataMsg.Destination)
+		m.emitMessage(EventBusy, dataMsg, "")
+		m.status.BusyReceived++
 
 	case message.ControlMachineNotExists:
-		// Destinatário não existe, remove da fila
-		log.Printf("[%s] Máquina %s não existe ou está desligada", m.config.MachineName, dataMsg.Destination)
+		// Destinatário não existe: pede a uma eventual caixa postal que guarde a mensagem,
+		// aproveitando que ainda possui o token
+		m.emitMessage(EventNotExists, dataMsg, "")
+		if m.mailbox != nil {
+			// Esta máquina é a própria caixa postal: guarda a mensagem localmente
+			if err := m.mailbox.Store(dataMsg); err != nil {
+				m.errorf("Caixa postal: erro ao guardar mensagem: %v", err)
+			} else {
+				m.logFrame(slog.LevelInfo, dataMsg, "Mensagem para %s adiada - guardada na caixa postal local", dataMsg.Destination)
+				m.status.MessagesDeferred++
+			}
+			m.queue.RemoveFirstMessage()
+			break
+		}
+		m.logFrame(slog.LevelWarn, dataMsg, "Máquina %s não existe ou está desligada - solicitando caixa postal", dataMsg.Destination)
+		dataMsg.SetControl(message.ControlStore)
+		m.waitingForData = true
+		m.currentDataMsg = dataMsg
+		m.sendPacket(dataMsg.RawData)
+		return
+
+	case message.ControlStore:
+		// Nenhuma caixa postal guardou a mensagem, remove da fila
+		m.logFrame(slog.LevelWarn, dataMsg, "Máquina %s não existe ou está desligada", dataMsg.Destination)
 		m.queue.RemoveFirstMessage()
+
+	case message.ControlDeferred:
+		// Mensagem guardada por uma caixa postal: será entregue quando o destino voltar
+		m.logFrame(slog.LevelInfo, dataMsg, "Mensagem para %s adiada - guardada na caixa postal", dataMsg.Destination)
+		m.queue.RemoveFirstMessage()
+		m.status.MessagesDeferred++
 	}
 
 	// Passa o token adiante após processar a resposta
 	m.passToken()
 }
 
+// handleBroadcastForThisMachine processa um broadcast de outra máquina ou uma mensagem
+// para um grupo multicast do qual esta máquina participa
+// Verifica a integridade usando CRC e registra ACK/NAK/BUSY na lista de confirmações do quadro
+func (m *Machine) handleBroadcastForThisMachine(dataMsg *message.DataMessage) {
+	name := m.config.MachineName
+
+	// Em uma retransmissão, estações que já confirmaram apenas repassam o quadro
+	if dataMsg.Receipt(name) == message.ControlACK {
+		m.logFrame(slog.LevelDebug, dataMsg, "Mensagem para %s de %s já recebida anteriormente", dataMsg.Destination, dataMsg.Origin)
+		m.forwardMessage(dataMsg)
+		return
+	}
+
+	if !dataMsg.VerifyIntegrity() {
+		m.logFrame(slog.LevelWarn, dataMsg, "Erro detectado na mensagem para %s de %s", dataMsg.Destination, dataMsg.Origin)
+		m.emitMessage(EventCRCError, dataMsg, "")
+		dataMsg.SetReceipt(name, message.ControlNAK)
+		m.status.ErrorsDetected++
+		if dataMsg.IsOrdered() {
+			m.missOrdered(dataMsg.Seq)
+		}
+	} else if dataMsg.IsOrdered() {
+		// Difusão com ordem total: entrega pela fila de retenção, na ordem de sequência
+		m.holdbackOrdered(dataMsg.Seq, dataMsg.Origin, dataMsg.Message)
+		m.status.MessagesReceived++
+		m.emitMessage(EventMessageDelivered, dataMsg, "")
+		dataMsg.SetReceipt(name, message.ControlACK)
+	} else if err := m.inbox.Add(dataMsg.Origin, dataMsg.Message); err != nil {
+		m.logFrame(slog.LevelWarn, dataMsg, "Receptor ocupado, recusando mensagem para %s de %s: %v", dataMsg.Destination, dataMsg.Origin, err)
+		m.emitMessage(EventFrameRefused, dataMsg, err.Error())
+		dataMsg.SetReceipt(name, message.ControlBusy)
+		m.status.BusyReplies++
+	} else {
+		m.logFrame(slog.LevelInfo, dataMsg, "Mensagem para %s recebida de %s: %s", dataMsg.Destination, dataMsg.Origin, dataMsg.Message)
+		m.emitMessage(EventMessageDelivered, dataMsg, "")
+		dataMsg.SetReceipt(name, message.ControlACK)
+		m.status.MessagesReceived++
+	}
+
+	// Encaminha o quadro para os demais membros
+	m.forwardMessage(dataMsg)
+}
+
+// handleReturnedBroadcast processa um broadcast ou multicast que completou o ciclo do anel
+// Relata quais estações confirmaram e mantém a mensagem na fila se alguma não recebeu
+// Executado no laço de eventos
+func (m *Machine) handleReturnedBroadcast(dataMsg *message.DataMessage) {
+	m.waitingForData = false
+	m.currentDataMsg = nil
+
+	report := newBroadcastReport(dataMsg)
+	m.lastBroadcast = report
+	m.logFrame(slog.LevelInfo, dataMsg, "Mensagem para %s completou o ciclo: %s", dataMsg.Destination, report)
+
+	switch {
+	case len(report.Failed) == 0:
+		m.emitMessage(EventACK, dataMsg, report.String())
+		m.observeDelivery()
+		m.queue.RemoveFirstMessage()
+
+	default:
+		// Retransmite no próximo token apenas para as estações que não confirmaram
+		m.logFrame(slog.LevelWarn, dataMsg, "Mensagem será retransmitida para: %s",
+			strings.Join(report.Failed, ", "))
+		m.queue.SetFirstMessageDelivered(report.Delivered)
+		if report.hasErrors() {
+			m.emitMessage(EventNAK, dataMsg, report.String())
+			m.queue.IncrementRetries()
+			m.status.Retransmissions++
+		} else {
+			m.emitMessage(EventBusy, dataMsg, report.String())
+			m.status.BusyReceived++
+		}
+	}
+
+	m.passToken()
+}
+
+// observeDelivery registra a latência de entrega da primeira mensagem da fila, confirmada agora,
+// o tempo desde a primeira transmissão e o número de retransmissões
+// Executado no laço de eventos
+func (m *Machine) observeDelivery() {
+	msg := m.queue.Peek()
+	if msg == nil {
+		return
+	}
+	now := m.systemNow()
+	histogram, ok := m.deliveryLatency[msg.Destination]
+	if !ok {
+		histogram = metrics.NewHistogram(metrics.LatencyBuckets)
+		m.deliveryLatency[msg.Destination] = histogram
+	}
+	histogram.Observe(now.Sub(msg.Timestamp).Seconds())
+	if !msg.Sent.IsZero() {
+		m.ackDelay.Observe(now.Sub(msg.Sent).Seconds())
+	}
+	m.deliveryRetries.Observe(float64(msg.Retries))
+}
+
 // forwardMessage encaminha uma mensagem para a próxima máquina na rede
 // Usado quando a mensagem não é para esta máquina
 func (m *Machine) forwardMessage(dataMsg *message.DataMessage) {
-	log.Printf("[%s] Repassando mensagem de %s para %s", m.config.MachineName, dataMsg.Origin, dataMsg.Destination)
+	m.logFrame(slog.LevelDebug, dataMsg, "Repassando mensagem de %s para %s", dataMsg.Origin, dataMsg.Destination)
 	m.sendPacket(dataMsg.RawData)
+	m.emitMessage(EventFrameForwarded, dataMsg, "")
 }
 
 // passToken libera o token e o envia para a próxima máquina na rede
@@ -348,27 +656,38 @@ func (m *Machine) passToken() {
 	m.hasToken = false
 	m.status.HasToken = false
 
-	// Cria e envia o pacote de token
-	tokenPacket := message.CreateTokenPacket()
+	// Cria e envia o pacote de token, levando o último número de sequência atribuído
+	tokenPacket := message.CreateSequencedTokenPacket(m.orderSeq)
 	m.sendPacket(tokenPacket)
 
-	log.Printf("[%s] Token enviado para próxima máquina", m.config.MachineName)
+	m.resetWatchdog()
+
+	m.debugf("Token enviado para próxima máquina")
+	m.emit(Event{Type: EventTokenPassed})
 }
 
 // sendPacket envia um pacote para a próxima máquina na rede
 // Utiliza o endereço configurado em NextMachineAddr
 func (m *Machine) sendPacket(data string) error {
-	// Resolve o endereço UDP da próxima máquina
-	addr, err := net.ResolveUDPAddr("udp", m.config.NextMachineAddr)
-	if err != nil {
-		return fmt.Errorf("erro ao resolver endereço: %v", err)
-	}
+	return m.sendPacketTo(m.config.NextMachineAddr, data)
+}
 
-	// Envia os dados via UDP
-	_, err = m.conn.WriteToUDP([]byte(data), addr)
-	if err != nil {
-		return fmt.Errorf("erro ao enviar pacote: %v", err)
+// sendPacketTo envia um pacote para o endereço informado
+func (m *Machine) sendPacketTo(address, data string) error {
+	// Todo envio é um evento do relógio lógico, registrado no cabeçalho do quadro
+	lamport, vector := m.clock.Send()
+	data = message.SetAttr(data, message.AttrLamport, strconv.Itoa(lamport))
+	data = message.SetAttr(data, message.AttrVector, vector)
+
+	// O horário da captura é o do envio, e não o do retorno de WriteTo
+	// Usa o relógio do sistema, sem o desvio nem as correções da sincronização, para que
+	// as capturas de várias estações do mesmo host sejam combinadas na ordem real
+	sentAt := m.systemNow()
+	if err := m.transport.WriteTo([]byte(data), address); err != nil {
+		return err
 	}
+	m.capturePacket(sentAt, capture.Sent, address, data)
+	m.emit(Event{Type: EventFrameSent, Frame: data})
 
 	return nil
 }
@@ -376,130 +695,308 @@ func (m *Machine) sendPacket(data string) error {
 // generateInitialToken gera e envia o token inicial para a rede
 // Chamado apenas pela máquina configurada para gerar o token
 func (m *Machine) generateInitialToken() {
-	log.Printf("[%s] Gerando token inicial", m.config.MachineName)
+	m.logf("Gerando token inicial")
 
 	// Atualiza estatísticas
-	m.mutex.Lock()
 	m.status.TokensGenerated++
-	m.mutex.Unlock()
+	m.resetWatchdog()
 
-	// Cria e envia o pacote de token
-	tokenPacket := message.CreateTokenPacket()
+	// Cria e envia o pacote de token, preservando a sequência da difusão com ordem total
+	tokenPacket := message.CreateSequencedTokenPacket(m.orderSeq)
 	err := m.sendPacket(tokenPacket)
 	if err != nil {
-		log.Printf("[%s] Erro ao enviar token inicial: %v", m.config.MachineName, err)
+		m.errorf("Erro ao enviar token inicial: %v", err)
+		return
 	}
+	m.emit(Event{Type: EventTokenGenerated})
 }
 
 // QueueMessage adiciona uma mensagem à fila para envio posterior
 // Chamado pela interface de usuário quando uma mensagem deve ser enviada
 func (m *Machine) QueueMessage(destination, content string) error {
-	return m.queue.Enqueue(destination, content)
+	var err error
+	m.do(func() {
+		err = m.queue.Enqueue(destination, content)
+	})
+	return err
 }
 
 // GetStatus retorna o status atual da máquina
 // Usado para exibir informações na interface de usuário
 func (m *Machine) GetStatus() MachineStatus {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	var status MachineStatus
+	m.do(func() {
+		status = m.currentStatus()
+	})
+	return status
+}
 
-	// Cria uma cópia do status para evitar condições de corrida
+// currentStatus retorna uma cópia do status com os tamanhos atuais
+// Executado no laço de eventos
+func (m *Machine) currentStatus() MachineStatus {
 	status := *m.status
 	status.QueueSize = m.queue.Size()
+	status.InboxSize = m.inbox.Size()
+	if m.mailbox != nil {
+		status.MailboxSize = m.mailbox.Size()
+	}
 	status.LastActivity = m.lastActivity
-
 	return status
 }
 
-// GetMessageQueue retorna todas as mensagens na fila
-// Usado para exibir a fila na interface de usuário
-func (m *Machine) GetMessageQueue() []*message.QueuedMessage {
-	return m.queue.GetAll()
+// GetMessageQueue retorna uma cópia das mensagens na fila
+// Usado para exibir a fila na interface de usuário; as cópias não mudam quando o laço
+// de eventos atualiza as mensagens (transmissão, tentativas, confirmações)
+func (m *Machine) GetMessageQueue() []message.QueuedMessage {
+	var messages []message.QueuedMessage
+	m.do(func() {
+		queued := m.queue.GetAll()
+		messages = make([]message.QueuedMessage, len(queued))
+		for i, msg := range queued {
+			messages[i] = *msg
+			messages[i].Delivered = append([]string(nil), msg.Delivered...)
+		}
+	})
+	return messages
+}
+
+// QueueCapacity retorna a capacidade da fila de envio
+func (m *Machine) QueueCapacity() int {
+	return m.queue.Capacity()
+}
+
+// GetInFlight retorna o quadro enviado por esta máquina que ainda não retornou
+// Retorna string vazia se a máquina não aguarda nenhum quadro
+func (m *Machine) GetInFlight() string {
+	var frame string
+	m.do(func() {
+		if m.waitingForData && m.currentDataMsg != nil {
+			frame = m.currentDataMsg.RawData
+		}
+	})
+	return frame
+}
+
+// GetInbox retorna as mensagens do buffer de recepção sem removê-las
+func (m *Machine) GetInbox() []*message.ReceivedMessage {
+	return m.inbox.GetAll()
+}
+
+// ReadInbox remove e retorna todas as mensagens do buffer de recepção
+// Libera espaço para que novos quadros deixem de ser recusados com BUSY
+func (m *Machine) ReadInbox() []*message.ReceivedMessage {
+	return m.inbox.ReceiveAll()
+}
+
+// GetLastBroadcastReport retorna o relatório de confirmações do último broadcast
+// Retorna nil se nenhum broadcast completou o ciclo ainda
+func (m *Machine) GetLastBroadcastReport() *BroadcastReport {
+	var report *BroadcastReport
+	m.do(func() {
+		report = m.lastBroadcast
+	})
+	return report
+}
+
+// JoinGroup faz a máquina participar de um grupo multicast
+// Mensagens endereçadas a @grupo passam a ser entregues a esta máquina
+func (m *Machine) JoinGroup(group string) error {
+	group = strings.TrimPrefix(group, message.GroupPrefix)
+	if err := config.ValidateGroupName(group); err != nil {
+		return err
+	}
+
+	address := message.GroupAddress(group)
+	var err error
+	m.do(func() {
+		if m.groups[address] {
+			err = fmt.Errorf("máquina já participa do grupo %s", address)
+			return
+		}
+		m.groups[address] = true
+		m.logf("Entrou no grupo %s", address)
+	})
+	return err
+}
+
+// LeaveGroup faz a máquina deixar um grupo multicast
+func (m *Machine) LeaveGroup(group string) error {
+	address := message.GroupAddress(group)
+	var err error
+	m.do(func() {
+		if !m.groups[address] {
+			err = fmt.Errorf("máquina não participa do grupo %s", address)
+			return
+		}
+		delete(m.groups, address)
+		m.logf("Saiu do grupo %s", address)
+	})
+	return err
+}
+
+// IsMember verifica se a máquina participa do grupo (com ou sem o prefixo @)
+func (m *Machine) IsMember(group string) bool {
+	var member bool
+	m.do(func() {
+		member = m.groups[message.GroupAddress(group)]
+	})
+	return member
+}
+
+// GetGroups retorna os endereços dos grupos dos quais a máquina participa, em ordem alfabética
+func (m *Machine) GetGroups() []string {
+	var groups []string
+	m.do(func() {
+		groups = m.groupList()
+	})
+	return groups
+}
+
+// groupList retorna os endereços dos grupos em ordem alfabética
+// Executado no laço de eventos
+func (m *Machine) groupList() []string {
+	groups := make([]string, 0, len(m.groups))
+	for group := range m.groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// GetMailbox retorna as mensagens guardadas pela caixa postal
+// Retorna erro se a máquina não atua como caixa postal
+func (m *Machine) GetMailbox() ([]MailboxEntry, error) {
+	if m.mailbox == nil {
+		return nil, fmt.Errorf("máquina não atua como caixa postal")
+	}
+	return m.mailbox.GetAll(), nil
 }
 
 // GenerateToken força a geração de um novo token
 // Só pode ser chamado se a máquina não possuir o token atualmente
 func (m *Machine) GenerateToken() error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	var err error
+	m.do(func() {
+		if m.hasToken {
+			err = fmt.Errorf("máquina já possui o token")
+			return
+		}
+		m.generateInitialToken()
+	})
+	return err
+}
 
-	if m.hasToken {
-		return fmt.Errorf("máquina já possui o token")
-	}
+// GetClock retorna o relógio lógico da máquina
+func (m *Machine) GetClock() *clock.Logical {
+	return m.clock
+}
 
-	go m.generateInitialToken()
-	return nil
+// observeClock registra o recebimento de um quadro no relógio lógico
+// Quadros sem relógio no cabeçalho contam apenas como evento local
+func (m *Machine) observeClock(data string) {
+	vector, err := clock.ParseVector(message.Attr(data, message.AttrVector))
+	if err != nil {
+		m.warnf("Relógio vetorial inválido no quadro: %v", err)
+	}
+	m.clock.Receive(message.IntAttr(data, message.AttrLamport), vector)
 }
 
-// isRunning verifica se a máquina está em execução
-// Usado no loop principal para determinar se deve continuar
-func (m *Machine) isRunning() bool {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return m.running
+// Now retorna o horário atual segundo a máquina (relógio do sistema corrigido pela sincronização)
+func (m *Machine) Now() time.Time {
+	return m.wallClock.Now()
 }
 
-// updateLastActivity atualiza o timestamp da última atividade
-// Chamado sempre que há comunicação na rede
-func (m *Machine) updateLastActivity() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	m.lastActivity = time.Now()
+// GetWallClock retorna o relógio físico corrigido da máquina
+func (m *Machine) GetWallClock() *clock.Physical {
+	return m.wallClock
 }
 
-// tokenWatchdog monitora a circulação do token na rede
-// Se o token não for visto por um tempo máximo, gera um novo token
-// Implementa um mecanismo de recuperação de falhas na rede
-func (m *Machine) tokenWatchdog() {
-	// Apenas a máquina autorizada a gerar tokens executa o watchdog
-	if !m.config.GeneratesToken {
+// logAttrs registra uma linha de log no nível informado
+// Cada linha leva o nome da máquina e o relógio lógico (lc e, se habilitado, vc), que permitem
+// ordenar causalmente os logs de estações diferentes; quando o horário da máquina difere do
+// relógio do sistema, leva também o horário corrigido (t)
+func (m *Machine) logAttrs(level slog.Level, attrs []slog.Attr, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !m.logger.Enabled(ctx, level) {
 		return
 	}
 
-	// Calcula o tempo máximo esperado para circulação do token
-	// Considera o tempo do token multiplicado pelo número estimado de máquinas
-	// e adiciona uma margem de segurança
-	maxTokenCirculationTime := time.Duration(m.config.TokenTime*3*2+3) * time.Second
-	ticker := time.NewTicker(maxTokenCirculationTime)
-	defer ticker.Stop()
-
-	// Inicializa o timestamp da última vez que o token foi visto
-	lastTokenSeen := time.Now()
-
-	for {
-		select {
-		// A cada tick, verifica se o token está circulando
-		case <-ticker.C:
-			m.mutex.RLock()
-			hasToken := m.hasToken
-			running := m.running
-			timeSinceLastToken := time.Since(lastTokenSeen)
-			m.mutex.RUnlock()
-
-			// Se a máquina não está mais em execução, encerra o watchdog
-			if !running {
-				return
-			}
+	line := []slog.Attr{
+		slog.String("maquina", m.config.MachineName),
+		slog.Int("lc", m.clock.Lamport()),
+	}
+	if vector := m.clock.Vector(); vector != nil {
+		line = append(line, slog.String("vc", clock.FormatVector(vector)))
+	}
+	if m.wallClock.Offset() != 0 {
+		line = append(line, slog.String("t", m.wallClock.Now().Format("15:04:05.000000")))
+	}
+	line = append(line, attrs...)
 
-			// Se o token não foi visto por muito tempo e esta máquina não o possui,
-			// assume que o token foi perdido e gera um novo
-			if timeSinceLastToken > maxTokenCirculationTime && !hasToken {
-				log.Printf("[%s] Token perdido! (último visto há %v) Gerando novo token...",
-					m.config.MachineName, timeSinceLastToken)
-				m.generateInitialToken()
-				lastTokenSeen = time.Now()
-			}
+	m.logger.LogAttrs(ctx, level, fmt.Sprintf(format, args...), line...)
+}
 
-		// No tempo entre ticks, verifica continuamente se a máquina possui o token
-		default:
-			m.mutex.RLock()
-			if m.hasToken {
-				lastTokenSeen = time.Now()
-			}
-			m.mutex.RUnlock()
+// frameAttrs retorna os campos de log de um quadro de dados
+func frameAttrs(dataMsg *message.DataMessage) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("origem", dataMsg.Origin),
+		slog.String("destino", dataMsg.Destination),
+		slog.String("controle", dataMsg.Control),
+	}
+	if dataMsg.Seq > 0 {
+		attrs = append(attrs, slog.Int("seq", dataMsg.Seq))
+	}
+	return attrs
+}
+
+// logf registra uma linha de log de nível info
+func (m *Machine) logf(format string, args ...interface{}) {
+	m.logAttrs(slog.LevelInfo, nil, format, args...)
+}
+
+// debugf registra uma linha de log de nível debug (ex: cada quadro e cada passagem do token)
+func (m *Machine) debugf(format string, args ...interface{}) {
+	m.logAttrs(slog.LevelDebug, nil, format, args...)
+}
+
+// warnf registra uma linha de log de nível aviso (ex: erros de CRC, destinos ocupados)
+func (m *Machine) warnf(format string, args ...interface{}) {
+	m.logAttrs(slog.LevelWarn, nil, format, args...)
+}
 
-			// Pequena pausa para não consumir CPU desnecessariamente
-			time.Sleep(1 * time.Second)
+// errorf registra uma linha de log de nível erro (falhas de envio, leitura ou gravação)
+func (m *Machine) errorf(format string, args ...interface{}) {
+	m.logAttrs(slog.LevelError, nil, format, args...)
+}
+
+// logFrame registra uma linha de log referente a um quadro de dados, com os campos do quadro
+func (m *Machine) logFrame(level slog.Level, dataMsg *message.DataMessage, format string, args ...interface{}) {
+	m.logAttrs(level, frameAttrs(dataMsg), format, args...)
+}
+
+// Logf registra uma linha de log de nível info no formato da máquina, para pacotes construídos sobre ela
+func (m *Machine) Logf(format string, args ...interface{}) {
+	m.logf(format, args...)
+}
+
+// Log registra uma linha de log no nível informado, no formato da máquina
+func (m *Machine) Log(level slog.Level, format string, args ...interface{}) {
+	m.logAttrs(level, nil, format, args...)
+}
+
+// GetTokenRotation retorna o histograma do tempo de volta do token, em segundos
+func (m *Machine) GetTokenRotation() metrics.HistogramSnapshot {
+	return m.tokenRotation.Snapshot()
+}
+
+// GetDeliveryLatency retorna os histogramas de latência de entrega por destino, em segundos
+func (m *Machine) GetDeliveryLatency() map[string]metrics.HistogramSnapshot {
+	var latency map[string]metrics.HistogramSnapshot
+	m.do(func() {
+		latency = make(map[string]metrics.HistogramSnapshot, len(m.deliveryLatency))
+		for destination, histogram := range m.deliveryLatency {
+			latency[destination] = histogram.Snapshot()
 		}
-	}
+	})
+	return latency
 }
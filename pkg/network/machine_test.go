@@ -0,0 +1,246 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"ring-network/pkg/config"
+	"ring-network/pkg/message"
+)
+
+// memoryNetwork liga transportes em memória pelo nome das estações
+type memoryNetwork struct {
+	mutex sync.Mutex
+	nodes map[string]*memoryTransport
+}
+
+// memoryFrame é um quadro em trânsito na rede em memória
+type memoryFrame struct {
+	data []byte
+	from string
+}
+
+// memoryTransport é um Transport em memória, endereçado pelo nome da estação
+type memoryTransport struct {
+	network *memoryNetwork
+	name    string
+	inbox   chan memoryFrame
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newMemoryNetwork() *memoryNetwork {
+	return &memoryNetwork{nodes: make(map[string]*memoryTransport)}
+}
+
+func (n *memoryNetwork) transport(name string) *memoryTransport {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	t := &memoryTransport{network: n, name: name, inbox: make(chan memoryFrame, 64), closed: make(chan struct{})}
+	n.nodes[name] = t
+	return t
+}
+
+func (t *memoryTransport) ReadFrom(buffer []byte, deadline time.Time) (int, string, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case frame := <-t.inbox:
+		return copy(buffer, frame.data), frame.from, nil
+	case <-timer.C:
+		return 0, "", os.ErrDeadlineExceeded
+	case <-t.closed:
+		return 0, "", net.ErrClosed
+	}
+}
+
+func (t *memoryTransport) WriteTo(data []byte, address string) error {
+	t.network.mutex.Lock()
+	to, ok := t.network.nodes[address]
+	t.network.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("estação desconhecida: %s", address)
+	}
+	select {
+	case to.inbox <- memoryFrame{data: append([]byte(nil), data...), from: t.name}:
+	case <-to.closed:
+	}
+	return nil
+}
+
+func (t *memoryTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+// testConfig retorna a configuração de uma estação do anel em memória
+func testConfig(name, next string) *config.Config {
+	return &config.Config{
+		NextMachineAddr:   next,
+		MachineName:       name,
+		TokenTime:         1,
+		ListenPort:        1,
+		ReceiveBufferSize: config.DefaultReceiveBufferSize,
+		MaxLockTime:       config.DefaultMaxLockTime,
+	}
+}
+
+// newTestRing cria um anel de duas máquinas em memória, sem erros introduzidos
+func newTestRing(t *testing.T) (*Machine, *Machine) {
+	t.Helper()
+	network := newMemoryNetwork()
+	names := []string{"a", "b"}
+	machines := make([]*Machine, len(names))
+	for i, name := range names {
+		cfg := testConfig(name, names[(i+1)%len(names)])
+		cfg.GeneratesToken = i == 0
+		m, err := New(
+			WithConfig(cfg),
+			WithTransport(network.transport(name)),
+			WithLogger(slog.New(slog.DiscardHandler)),
+			WithTokenTime(time.Millisecond),
+			WithErrorProbability(0),
+			WithRand(rand.New(rand.NewSource(int64(i)))),
+		)
+		if err != nil {
+			t.Fatalf("New(%s): %v", name, err)
+		}
+		machines[i] = m
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, m := range machines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Run(ctx); err != nil {
+				t.Errorf("Run(%s): %v", m.Name(), err)
+			}
+		}()
+	}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return machines[0], machines[1]
+}
+
+// TestMachineDeliversOverMemoryTransport envia mensagens e difusões ordenadas entre duas máquinas
+// enquanto outra goroutine lê o estado público; com -race, verifica que as leituras não
+// disputam o estado alterado pelo laço de eventos
+func TestMachineDeliversOverMemoryTransport(t *testing.T) {
+	a, b := newTestRing(t)
+
+	acks, unsubscribe := a.Subscribe(64)
+	defer unsubscribe()
+
+	var ordered sync.WaitGroup
+	ordered.Add(2)
+	for _, m := range []*Machine{a, b} {
+		m.OnOrderedDelivery(func(msg OrderedMessage) {
+			if msg.Content == "ordenada" {
+				ordered.Done()
+			}
+		})
+	}
+
+	const count = 3
+	for i := range count {
+		if err := a.QueueMessage("b", fmt.Sprintf("mensagem %d", i)); err != nil {
+			t.Fatalf("QueueMessage: %v", err)
+		}
+	}
+	if err := a.BroadcastOrdered("ordenada"); err != nil {
+		t.Fatalf("BroadcastOrdered: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for _, msg := range a.GetMessageQueue() {
+				_ = fmt.Sprint(msg.Sent, msg.Seq, msg.Retries, msg.Delivered)
+			}
+			a.GetStatus()
+			a.GetOrderStatus()
+			b.GetInbox()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	defer func() {
+		close(stop)
+		readers.Wait()
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for confirmed := 0; confirmed < count+1; {
+		select {
+		case event := <-acks:
+			if event.Type == EventACK {
+				confirmed++
+			}
+		case <-timeout:
+			t.Fatalf("apenas %d de %d mensagens confirmadas; fila: %v", confirmed, count+1, a.GetMessageQueue())
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ordered.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-timeout:
+		t.Fatal("difusão ordenada não foi entregue às duas máquinas")
+	}
+
+	if queued := a.GetMessageQueue(); len(queued) != 0 {
+		t.Errorf("fila de a deveria estar vazia: %v", queued)
+	}
+	inbox := b.GetInbox()
+	if len(inbox) != count {
+		t.Fatalf("b recebeu %d mensagens, esperava %d", len(inbox), count)
+	}
+	for i, msg := range inbox {
+		if want := fmt.Sprintf("mensagem %d", i); msg.Origin != "a" || msg.Content != want {
+			t.Errorf("mensagem %d: de %s %q, esperava de a %q", i, msg.Origin, msg.Content, want)
+		}
+	}
+}
+
+// TestGetMessageQueueReturnsCopies verifica que a cópia retornada não acompanha a fila
+func TestGetMessageQueueReturnsCopies(t *testing.T) {
+	m, err := New(WithConfig(testConfig("a", "b")), WithTransport(newMemoryNetwork().transport("a")), WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer m.Stop()
+
+	if err := m.QueueMessage(message.BroadcastAddress, "oi"); err != nil {
+		t.Fatalf("QueueMessage: %v", err)
+	}
+	copied := m.GetMessageQueue()
+	m.do(func() {
+		m.queue.IncrementRetries()
+		m.queue.GetAll()[0].Delivered = append(m.queue.GetAll()[0].Delivered, "b")
+	})
+	if copied[0].Retries != 0 || len(copied[0].Delivered) != 0 {
+		t.Errorf("cópia alterada pela fila: %+v", copied[0])
+	}
+}
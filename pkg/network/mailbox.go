@@ -0,0 +1,227 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// MailboxEntry representa uma mensagem guardada pela caixa postal
+// para um destino que estava ausente do anel
+type MailboxEntry struct {
+	Origin      string    `json:"origem"`     // Origem original da mensagem
+	Destination string    `json:"destino"`    // Destino ausente
+	Message     string    `json:"mensagem"`   // Conteúdo da mensagem
+	Stored      time.Time `json:"guardada"`   // Momento em que foi guardada
+	Attempts    int       `json:"tentativas"` // Número de tentativas de entrega
+}
+
+// Mailbox guarda em disco as mensagens para destinos ausentes
+// e as entrega quando o destino volta ao anel
+type Mailbox struct {
+	file    string          // Arquivo JSON onde as mensagens são persistidas
+	entries []*MailboxEntry // Mensagens guardadas
+	next    int             // Próxima mensagem a tentar entregar (rodízio)
+	mutex   sync.Mutex      // Mutex para acesso concorrente
+}
+
+// NewMailbox cria a caixa postal e carrega as mensagens já guardadas no arquivo
+func NewMailbox(file string) (*Mailbox, error) {
+	mb := &Mailbox{file: file}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return mb, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler caixa postal: %v", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &mb.entries); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar caixa postal: %v", err)
+		}
+	}
+
+	return mb, nil
+}
+
+// Store guarda uma mensagem e persiste a caixa postal em disco
+func (mb *Mailbox) Store(dataMsg *message.DataMessage) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	mb.entries = append(mb.entries, &MailboxEntry{
+		Origin:      dataMsg.Origin,
+		Destination: dataMsg.Destination,
+		Message:     dataMsg.Message,
+		Stored:      time.Now(),
+	})
+
+	return mb.save()
+}
+
+// NextDelivery retorna a próxima mensagem a ser entregue, em rodízio
+// Retorna nil se a caixa postal estiver vazia
+func (mb *Mailbox) NextDelivery() *MailboxEntry {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	if len(mb.entries) == 0 {
+		return nil
+	}
+
+	if mb.next >= len(mb.entries) {
+		mb.next = 0
+	}
+	entry := mb.entries[mb.next]
+	entry.Attempts++
+	mb.next++
+
+	return entry
+}
+
+// Remove retira uma mensagem entregue e persiste a caixa postal em disco
+func (mb *Mailbox) Remove(entry *MailboxEntry) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	for i, e := range mb.entries {
+		if e == entry {
+			mb.entries = append(mb.entries[:i], mb.entries[i+1:]...)
+			if mb.next > i {
+				mb.next--
+			}
+			return mb.save()
+		}
+	}
+
+	return nil
+}
+
+// GetAll retorna uma cópia das mensagens guardadas
+func (mb *Mailbox) GetAll() []MailboxEntry {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	result := make([]MailboxEntry, len(mb.entries))
+	for i, e := range mb.entries {
+		result[i] = *e
+	}
+
+	return result
+}
+
+// Size retorna o número de mensagens guardadas
+func (mb *Mailbox) Size() int {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	return len(mb.entries)
+}
+
+// save grava as mensagens no arquivo da caixa postal
+// Deve ser chamado com o mutex já adquirido
+func (mb *Mailbox) save() error {
+	data, err := json.MarshalIndent(mb.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao codificar caixa postal: %v", err)
+	}
+
+	// Grava em arquivo temporário e renomeia para não corromper o arquivo existente
+	tmp := mb.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("erro ao gravar caixa postal: %v", err)
+	}
+	if err := os.Rename(tmp, mb.file); err != nil {
+		return fmt.Errorf("erro ao gravar caixa postal: %v", err)
+	}
+
+	return nil
+}
+
+// matches verifica se um quadro corresponde a esta mensagem guardada
+func (e *MailboxEntry) matches(dataMsg *message.DataMessage) bool {
+	return e.Origin == dataMsg.Origin && e.Destination == dataMsg.Destination && e.Message == dataMsg.Message
+}
+
+// storeInMailbox guarda uma mensagem para um destino ausente
+// Marca o quadro como adiado para que a origem saiba que não foi perdido
+func (m *Machine) storeInMailbox(dataMsg *message.DataMessage) {
+	if !dataMsg.VerifyIntegrity() {
+		// Mensagem corrompida: pede retransmissão em vez de guardar
+		m.logFrame(slog.LevelWarn, dataMsg, "Caixa postal: erro detectado na mensagem de %s para %s", dataMsg.Origin, dataMsg.Destination)
+		dataMsg.SetControl(message.ControlNAK)
+	} else if err := m.mailbox.Store(dataMsg); err != nil {
+		m.errorf("Caixa postal: erro ao guardar mensagem: %v", err)
+	} else {
+		m.logFrame(slog.LevelInfo, dataMsg, "Caixa postal: mensagem de %s para %s guardada", dataMsg.Origin, dataMsg.Destination)
+		dataMsg.SetControl(message.ControlDeferred)
+	}
+
+	m.forwardMessage(dataMsg)
+}
+
+// relayMailboxEntry tenta entregar uma mensagem guardada na caixa postal
+// O quadro mantém a origem original para que o destino saiba quem enviou
+// Executado no laço de eventos, com a posse do token
+func (m *Machine) relayMailboxEntry() {
+	entry := m.mailbox.NextDelivery()
+	if entry == nil {
+		m.passToken()
+		return
+	}
+
+	dataMsg := message.CreateDataPacket(entry.Origin, entry.Destination, entry.Message)
+	dataMsg.SetControl(message.ControlRelay)
+	if dataMsg.IntroduceError(m.rng, m.errorProbability) {
+		m.logFrame(slog.LevelWarn, dataMsg, "Erro introduzido na mensagem para %s", entry.Destination)
+	}
+
+	m.waitingForData = true
+	m.currentDataMsg = dataMsg
+	m.currentRelay = entry
+
+	m.sendPacket(dataMsg.RawData)
+	m.status.MessagesSent++
+
+	m.logFrame(slog.LevelInfo, dataMsg, "Caixa postal: tentando entregar mensagem de %s para %s (tentativa %d)",
+		entry.Origin, entry.Destination, entry.Attempts)
+}
+
+// handleRelayedMessage processa um quadro reenviado por uma caixa postal
+// Se for a entrega em andamento desta caixa postal, trata a resposta do destino;
+// caso contrário apenas repassa o quadro (inclusive na origem original)
+// Executado no laço de eventos
+func (m *Machine) handleRelayedMessage(dataMsg *message.DataMessage) {
+	entry := m.currentRelay
+	if !m.waitingForData || entry == nil || !entry.matches(dataMsg) {
+		m.forwardMessage(dataMsg)
+		return
+	}
+
+	m.waitingForData = false
+	m.currentDataMsg = nil
+	m.currentRelay = nil
+
+	switch message.RelayStatus(dataMsg.Control) {
+	case message.ControlACK:
+		m.logFrame(slog.LevelInfo, dataMsg, "Caixa postal: mensagem de %s entregue a %s", entry.Origin, entry.Destination)
+		if err := m.mailbox.Remove(entry); err != nil {
+			m.errorf("Caixa postal: %v", err)
+		}
+	case message.ControlNAK, message.ControlBusy:
+		m.logFrame(slog.LevelWarn, dataMsg, "Caixa postal: entrega para %s falhou (%s), nova tentativa depois",
+			entry.Destination, message.RelayStatus(dataMsg.Control))
+	default:
+		m.logFrame(slog.LevelInfo, dataMsg, "Caixa postal: %s continua ausente", entry.Destination)
+	}
+
+	m.passToken()
+}
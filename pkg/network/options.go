@@ -0,0 +1,126 @@
+package network
+
+import (
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/clock"
+	"ring-network/pkg/config"
+)
+
+// Valores padrão das opções da máquina
+const (
+	DefaultQueueCapacity    = 10  // Capacidade padrão da fila de envio
+	DefaultErrorProbability = 0.1 // Probabilidade padrão de introduzir erro em uma transmissão
+)
+
+// Option configura uma máquina criada com New
+type Option func(*options)
+
+// options reúne as opções de New antes da criação da máquina
+type options struct {
+	config           *config.Config
+	transport        Transport
+	logger           *slog.Logger
+	queueCapacity    int
+	wallClock        *clock.Physical
+	rng              *rand.Rand
+	errorProbability float64
+	capture          capture.Recorder
+	scheduler        Scheduler
+	tokenTime        time.Duration
+}
+
+// WithConfig define a configuração da máquina (obrigatória)
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithTransport define o meio de troca de quadros
+// Padrão: UDP na porta de escuta da configuração
+func WithTransport(transport Transport) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithLogger define o logger estruturado que recebe as linhas de log da máquina
+// (ver o pacote logging para arquivo com rotação, formato JSON e cópia no terminal)
+// Padrão: slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithQueueCapacity define a capacidade da fila de envio
+// Padrão: DefaultQueueCapacity
+func WithQueueCapacity(capacity int) Option {
+	return func(o *options) {
+		o.queueCapacity = capacity
+	}
+}
+
+// WithClock define o horário da máquina (ex: um relógio compartilhado em uma simulação)
+// Padrão: o relógio do sistema com o desvio da configuração (desvio_relogio)
+func WithClock(wallClock *clock.Physical) Option {
+	return func(o *options) {
+		o.wallClock = wallClock
+	}
+}
+
+// WithRand define o gerador de números aleatórios usado para introduzir erros
+// Uma semente fixa torna os erros reproduzíveis; nil é recusado por New
+func WithRand(rng *rand.Rand) Option {
+	return func(o *options) {
+		o.rng = rng
+	}
+}
+
+// WithErrorProbability define a probabilidade de introduzir erro em cada transmissão, entre 0 e 1
+// Padrão: DefaultErrorProbability
+func WithErrorProbability(probability float64) Option {
+	return func(o *options) {
+		o.errorProbability = probability
+	}
+}
+
+// WithCapture grava cada quadro enviado e recebido na captura informada (pcapng ou JSONL, ver capture.Open)
+// A máquina não fecha a captura; isso cabe a quem a criou
+// Padrão: sem captura
+func WithCapture(w capture.Recorder) Option {
+	return func(o *options) {
+		o.capture = w
+	}
+}
+
+// WithScheduler entrega o horário e os temporizadores da máquina ao Scheduler (ex: um simulador de eventos discretos)
+// A máquina não inicia goroutines: é iniciada com Begin e recebe os quadros por Deliver
+// Padrão: relógio do sistema, com o laço de eventos
+func WithScheduler(scheduler Scheduler) Option {
+	return func(o *options) {
+		o.scheduler = scheduler
+	}
+}
+
+// WithTokenTime define o tempo de posse do token com resolução menor que o segundo (zero = sem espera)
+// Padrão: o tempo do token da configuração (tempo_token), em segundos
+func WithTokenTime(d time.Duration) Option {
+	return func(o *options) {
+		o.tokenTime = d
+	}
+}
+
+// defaultOptions retorna as opções padrão
+func defaultOptions() *options {
+	return &options{
+		queueCapacity:    DefaultQueueCapacity,
+		rng:              rand.New(rand.NewSource(time.Now().UnixNano())),
+		errorProbability: DefaultErrorProbability,
+		tokenTime:        -1,
+	}
+}
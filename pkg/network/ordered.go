@@ -0,0 +1,168 @@
+package network
+
+import (
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// Difusão com ordem total
+//
+// Como apenas o portador do token transmite, o próprio token funciona como
+// sequenciador: ele carrega o último número de sequência atribuído e o portador
+// atribui o próximo número à sua difusão. Cada estação entrega as mensagens
+// estritamente na ordem dos números, retendo as que chegam antes de uma lacuna
+// (por exemplo, após um NAK) até que a mensagem faltante seja retransmitida.
+
+// OrderedMessage representa uma mensagem entregue pela difusão com ordem total
+type OrderedMessage struct {
+	Seq       int       // Número de sequência (ordem global de entrega)
+	Origin    string    // Máquina que originou a mensagem
+	Content   string    // Conteúdo da mensagem
+	Delivered time.Time // Momento da entrega nesta máquina
+}
+
+// OrderedHandler é chamada para cada mensagem entregue, em ordem de sequência
+type OrderedHandler func(OrderedMessage)
+
+// OrderStatus resume o estado da difusão com ordem total nesta máquina
+type OrderStatus struct {
+	LastSeq      int // Maior número de sequência conhecido
+	NextDeliver  int // Próximo número a ser entregue
+	Pending      int // Mensagens retidas aguardando uma lacuna ser preenchida
+	Delivered    int // Mensagens entregues à aplicação
+	QueuedToSend int // Difusões desta máquina ainda não confirmadas por todas as estações
+}
+
+// BroadcastOrdered adiciona à fila uma difusão com ordem total
+// Todas as estações (inclusive esta) entregam as difusões na mesma ordem
+func (m *Machine) BroadcastOrdered(content string) error {
+	msg := message.NewQueuedMessage(message.BroadcastAddress, content)
+	msg.Ordered = true
+	msg.Timestamp = m.systemNow()
+
+	var err error
+	m.do(func() {
+		err = m.queue.EnqueueMessage(msg)
+	})
+	return err
+}
+
+// OnOrderedDelivery registra uma função chamada a cada mensagem entregue pela difusão com ordem total
+// As funções são chamadas na goroutine de despacho, na ordem de sequência
+func (m *Machine) OnOrderedDelivery(handler OrderedHandler) {
+	m.do(func() {
+		m.orderedHandlers = append(m.orderedHandlers, handler)
+	})
+}
+
+// GetOrderStatus retorna o estado da difusão com ordem total
+func (m *Machine) GetOrderStatus() OrderStatus {
+	var status OrderStatus
+	m.do(func() {
+		status = OrderStatus{
+			LastSeq:     m.orderSeq,
+			NextDeliver: m.nextDeliver,
+			Pending:     len(m.holdback),
+			Delivered:   m.orderedDelivered,
+		}
+		for _, msg := range m.queue.GetAll() {
+			if msg.Ordered {
+				status.QueuedToSend++
+			}
+		}
+	})
+	return status
+}
+
+// observeSeq atualiza o maior número de sequência conhecido
+// Executado no laço de eventos
+func (m *Machine) observeSeq(seq int) {
+	if seq > m.orderSeq {
+		m.orderSeq = seq
+	}
+}
+
+// observeTokenSeq atualiza o estado de ordenação ao receber o token
+// Na primeira vez, define a partir de qual número esta máquina começa a entregar
+// Executado no laço de eventos
+func (m *Machine) observeTokenSeq(seq int) {
+	m.observeSeq(seq)
+	m.startDelivery(m.orderSeq + 1)
+}
+
+// assignSeq atribui o próximo número de sequência a uma difusão desta máquina
+// Executado no laço de eventos e com a posse do token
+func (m *Machine) assignSeq() int {
+	m.orderSeq++
+	m.startDelivery(m.orderSeq)
+	return m.orderSeq
+}
+
+// missOrdered registra uma difusão ordenada recusada por erro (NAK)
+// O número do cabeçalho não é protegido pelo CRC e não altera orderSeq, mas, se a entrega
+// ainda não começou, a mensagem não pode ser pulada: a origem vai retransmiti-la a esta estação
+// Executado no laço de eventos
+func (m *Machine) missOrdered(seq int) {
+	if m.nextDeliver == 0 && (m.missedSeq == 0 || seq < m.missedSeq) {
+		m.missedSeq = seq
+	}
+}
+
+// startDelivery define, na primeira vez, o número a partir do qual esta máquina entrega
+// Começa antes se uma difusão anterior foi recusada por erro (missOrdered)
+// Executado no laço de eventos
+func (m *Machine) startDelivery(seq int) {
+	if m.nextDeliver != 0 {
+		return
+	}
+	if m.missedSeq > 0 && m.missedSeq < seq {
+		seq = m.missedSeq
+	}
+	m.nextDeliver = seq
+	m.missedSeq = 0
+}
+
+// holdbackOrdered insere uma mensagem na fila de retenção e entrega
+// todas as que estiverem na sequência esperada
+// Executado no laço de eventos
+func (m *Machine) holdbackOrdered(seq int, origin, content string) {
+	m.observeSeq(seq)
+	m.startDelivery(seq)
+
+	// Mensagem já entregue (retransmissão)
+	if seq < m.nextDeliver {
+		return
+	}
+
+	if _, exists := m.holdback[seq]; !exists {
+		m.holdback[seq] = OrderedMessage{Seq: seq, Origin: origin, Content: content}
+	}
+
+	// Entrega em ordem enquanto não houver lacunas
+	for {
+		msg, ok := m.holdback[m.nextDeliver]
+		if !ok {
+			break
+		}
+		delete(m.holdback, m.nextDeliver)
+		m.nextDeliver++
+
+		msg.Delivered = m.systemNow()
+		m.orderedDelivered++
+		m.logf("Entrega ordenada #%d de %s: %s", msg.Seq, msg.Origin, msg.Content)
+
+		// As funções registradas são chamadas fora do laço, para que possam usar os métodos da máquina
+		handlers := append([]OrderedHandler(nil), m.orderedHandlers...)
+		m.dispatch(func() {
+			for _, handler := range handlers {
+				handler(msg)
+			}
+		})
+	}
+
+	if len(m.holdback) > 0 {
+		m.logf("%d mensagens ordenadas retidas aguardando #%d",
+			len(m.holdback), m.nextDeliver)
+	}
+}
@@ -0,0 +1,158 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// Diagnóstico do anel (ping e trace)
+//
+// O pacote de diagnóstico circula sem depender do token, como os demais pacotes
+// de controle. Como o anel é unidirecional, o destino não responde pelo caminho
+// inverso: ele registra em quantos enlaces foi alcançado e repassa o pacote, que
+// completa a volta até a origem. O tempo de volta (RTT) é, portanto, o tempo de
+// uma volta completa no anel, e o número de estações do anel vem de brinde.
+
+// PingResult é o resultado de um ping
+type PingResult struct {
+	Destination string        `json:"destino"`
+	RTT         time.Duration `json:"rtt_ns"`   // Tempo de volta completa no anel
+	Hops        int           `json:"saltos"`   // Enlaces da origem até o destino
+	RingSize    int           `json:"estacoes"` // Enlaces da volta completa (número de estações)
+}
+
+// TraceHop é a passagem do pacote de trace por uma estação
+// O horário é o do relógio da estação: sem sincronização (sync), o intervalo
+// entre estações inclui a diferença entre os relógios e pode ser negativo
+type TraceHop struct {
+	Station     string        `json:"maquina"`
+	Time        time.Time     `json:"horario"`
+	Elapsed     time.Duration `json:"desde_anterior_ns"` // Desde a passagem pela estação anterior
+	Destination bool          `json:"destino,omitempty"`
+}
+
+// TraceResult é o resultado de um trace: as estações na ordem do anel, da origem de volta à origem
+type TraceResult struct {
+	Destination string        `json:"destino"`
+	Reached     bool          `json:"alcancado"` // O destino está no anel
+	Hops        int           `json:"saltos"`    // Enlaces da origem até o destino
+	RTT         time.Duration `json:"rtt_ns"`
+	Path        []TraceHop    `json:"caminho"`
+}
+
+// probeState acompanha um pacote de diagnóstico enviado por esta máquina
+type probeState struct {
+	sent  time.Time
+	done  chan struct{}
+	probe *message.Probe // Pacote retornado
+	rtt   time.Duration
+}
+
+// Ping envia um eco até a máquina de destino e aguarda o seu retorno
+// Retorna erro se o pacote completar a volta sem passar pelo destino
+func (m *Machine) Ping(ctx context.Context, destination string) (PingResult, error) {
+	probe, rtt, err := m.sendProbe(ctx, message.EchoPacket, destination)
+	if err != nil {
+		return PingResult{}, err
+	}
+	if probe.Reached == 0 {
+		return PingResult{}, fmt.Errorf("máquina %s não encontrada no anel (%d estações)", destination, probe.Hops)
+	}
+	return PingResult{Destination: destination, RTT: rtt, Hops: probe.Reached, RingSize: probe.Hops}, nil
+}
+
+// Trace envia um pacote de trace até a máquina de destino e aguarda o seu retorno
+// O caminho lista todas as estações por onde o pacote passou, mesmo se o destino não estiver no anel
+func (m *Machine) Trace(ctx context.Context, destination string) (TraceResult, error) {
+	probe, rtt, err := m.sendProbe(ctx, message.TracePacket, destination)
+	if err != nil {
+		return TraceResult{}, err
+	}
+
+	result := TraceResult{Destination: destination, Reached: probe.Reached > 0, Hops: probe.Reached, RTT: rtt}
+	for i, hop := range probe.Path {
+		traceHop := TraceHop{Station: hop.Station, Time: hop.Time, Destination: result.Reached && i == probe.Reached}
+		if i > 0 {
+			traceHop.Elapsed = hop.Time.Sub(probe.Path[i-1].Time)
+		}
+		result.Path = append(result.Path, traceHop)
+	}
+	return result, nil
+}
+
+// sendProbe envia um pacote de diagnóstico e aguarda o seu retorno à origem
+func (m *Machine) sendProbe(ctx context.Context, packetType, destination string) (*message.Probe, time.Duration, error) {
+	switch {
+	case destination == "":
+		return nil, 0, fmt.Errorf("destino não informado")
+	case destination == m.config.MachineName:
+		return nil, 0, fmt.Errorf("o destino deve ser outra máquina")
+	case destination == message.BroadcastAddress || message.IsGroupAddress(destination):
+		return nil, 0, fmt.Errorf("o destino deve ser uma única máquina: %s", destination)
+	}
+
+	var id string
+	var state *probeState
+	var err error
+	m.do(func() {
+		m.probeCounter++
+		id = fmt.Sprintf("%s-%d", m.config.MachineName, m.probeCounter)
+		probe := message.CreateProbe(packetType, id, m.config.MachineName, destination, m.Now())
+		state = &probeState{sent: m.systemNow(), done: make(chan struct{})}
+		if err = m.sendPacket(probe.String()); err != nil {
+			return
+		}
+		m.probes[id] = state
+		m.debugf("%s %s enviado para %s", message.PacketName(packetType), id, destination)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	select {
+	case <-state.done:
+		return state.probe, state.rtt, nil
+	case <-ctx.Done():
+		m.do(func() {
+			delete(m.probes, id)
+		})
+		return nil, 0, ctx.Err()
+	}
+}
+
+// handleProbe processa um pacote de ping ou trace recebido
+// Executado no laço de eventos
+func (m *Machine) handleProbe(data string) {
+	probe, err := message.ParseProbe(data)
+	if err != nil {
+		m.errorf("Erro ao parsear pacote de diagnóstico: %v", err)
+		return
+	}
+
+	if probe.Origin != m.config.MachineName {
+		// Registra a passagem por esta estação e repassa, inclusive no destino
+		if probe.Arrive(m.config.MachineName, m.Now()) {
+			m.debugf("%s %s de %s alcançou esta máquina em %d saltos", message.PacketName(probe.Type), probe.ID, probe.Origin, probe.Reached)
+		}
+		if err := m.sendPacket(probe.String()); err != nil {
+			m.errorf("Erro ao repassar pacote de diagnóstico: %v", err)
+		}
+		return
+	}
+
+	// O pacote completou a volta
+	state, ok := m.probes[probe.ID]
+	if !ok {
+		m.warnf("Pacote de diagnóstico desconhecido ou cancelado: %s", probe.ID)
+		return
+	}
+	delete(m.probes, probe.ID)
+
+	probe.Arrive(m.config.MachineName, m.Now())
+	state.probe = probe
+	state.rtt = m.systemNow().Sub(state.sent)
+	close(state.done)
+}
@@ -0,0 +1,108 @@
+package network
+
+import (
+	"fmt"
+	"time"
+)
+
+// Máquina controlada por um Scheduler
+//
+// Com WithScheduler, a máquina não inicia o laço de eventos nem lê o transporte:
+// quem a controla (ex: um simulador de eventos discretos) entrega os quadros com
+// Deliver e dispara os temporizadores, que são agendados no Scheduler em vez do
+// relógio do sistema. Cada entrega, disparo ou chamada de método público é
+// processado até o fim na goroutine de quem chamou, protegido pelo mutex, e as
+// funções de despacho (tratamento de pacotes de controle) rodam logo em seguida.
+// Todas as chamadas devem vir da mesma goroutine; métodos que aguardam a rede
+// (Acquire, Ping, Trace, Snapshot) bloqueariam o próprio Scheduler.
+
+// Scheduler fornece o horário e os temporizadores de uma máquina fora do tempo real
+type Scheduler interface {
+	// Now retorna o horário atual do Scheduler
+	Now() time.Time
+
+	// AfterFunc agenda fn para daqui a d e retorna a função que cancela o agendamento
+	AfterFunc(d time.Duration, fn func()) (cancel func())
+}
+
+// timer é um temporizador da máquina: do relógio do sistema, selecionado no laço de eventos,
+// ou agendado no Scheduler
+type timer struct {
+	t      *time.Timer
+	cancel func()
+}
+
+// newTimer arma um temporizador que chama fire no laço de eventos após d
+// Executado no laço de eventos
+func (m *Machine) newTimer(d time.Duration, fire func()) *timer {
+	if m.scheduler != nil {
+		return &timer{cancel: m.scheduler.AfterFunc(d, func() { m.do(fire) })}
+	}
+	return &timer{t: time.NewTimer(d)}
+}
+
+// timerC retorna o canal do temporizador, ou nil (nunca pronto) se não estiver armado
+// ou se for agendado no Scheduler
+func timerC(t *timer) <-chan time.Time {
+	if t == nil || t.t == nil {
+		return nil
+	}
+	return t.t.C
+}
+
+// stopTimer desarma o temporizador, se armado
+// Após a parada, o canal do temporizador não entrega mais o disparo antigo
+func stopTimer(t **timer) {
+	if *t == nil {
+		return
+	}
+	if (*t).t != nil {
+		(*t).t.Stop()
+	}
+	if (*t).cancel != nil {
+		(*t).cancel()
+	}
+	*t = nil
+}
+
+// systemNow retorna o horário usado para medir intervalos: o relógio do sistema ou o do Scheduler
+// Diferente de Now, não sofre as correções da sincronização de relógios
+func (m *Machine) systemNow() time.Time {
+	if m.scheduler != nil {
+		return m.scheduler.Now()
+	}
+	return time.Now()
+}
+
+// Begin inicia a operação de uma máquina controlada por um Scheduler (WithScheduler) e retorna em seguida
+// Os quadros chegam por Deliver e os temporizadores disparam pelo Scheduler
+func (m *Machine) Begin() error {
+	if m.scheduler == nil {
+		return fmt.Errorf("máquina sem Scheduler: use Run")
+	}
+	return m.begin()
+}
+
+// runCallbacks executa as funções de despacho pendentes, na ordem (máquina com Scheduler)
+// Chamadas aninhadas (uma função de despacho que usa a máquina) deixam a execução para a chamada externa
+func (m *Machine) runCallbacks() {
+	if m.inCallbacks {
+		return
+	}
+	m.inCallbacks = true
+	defer func() { m.inCallbacks = false }()
+
+	for {
+		m.callbackMutex.Lock()
+		callbacks := m.callbacks
+		m.callbacks = nil
+		m.callbackMutex.Unlock()
+
+		if len(callbacks) == 0 {
+			return
+		}
+		for _, fn := range callbacks {
+			fn()
+		}
+	}
+}
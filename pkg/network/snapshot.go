@@ -0,0 +1,197 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// Snapshot distribuído (algoritmo de Chandy–Lamport)
+//
+// O iniciador grava seu estado, passa a gravar o canal de entrada e envia um
+// marcador. Cada estação, ao receber o marcador, grava seu estado e o repassa
+// imediatamente; como o anel é unidirecional e o canal é FIFO, o canal de
+// entrada dessas estações está vazio nesse momento. Quando o marcador retorna,
+// os quadros gravados pelo iniciador formam o estado do único canal que pode
+// conter quadros em trânsito. O marcador acumula os estados gravados, de modo
+// que o iniciador recebe o snapshot completo sem mensagens adicionais.
+
+// StationSnapshot representa o estado gravado de uma estação
+type StationSnapshot struct {
+	Machine        string                     `json:"maquina"`
+	Recorded       time.Time                  `json:"gravado_em"`
+	Status         MachineStatus              `json:"status"`
+	Queue          []*message.QueuedMessage   `json:"fila"`
+	Inbox          []*message.ReceivedMessage `json:"buffer_recepcao"`
+	WaitingFor     string                     `json:"aguardando_quadro,omitempty"` // Quadro enviado aguardando retorno
+	LockHeld       bool                       `json:"secao_critica"`
+	OrderSeq       int                        `json:"sequencia_ordem_total"`
+	Groups         []string                   `json:"grupos,omitempty"`
+	MailboxEntries int                        `json:"caixa_postal,omitempty"`
+}
+
+// Snapshot representa um estado global consistente do anel
+type Snapshot struct {
+	ID        string            `json:"id"`
+	Initiator string            `json:"iniciador"`
+	Started   time.Time         `json:"inicio"`
+	Completed time.Time         `json:"fim"`
+	Stations  []StationSnapshot `json:"estacoes"`
+	InFlight  []string          `json:"quadros_em_transito"` // Estado do canal de entrada do iniciador
+}
+
+// snapshotState acompanha um snapshot iniciado por esta máquina
+type snapshotState struct {
+	snapshot *Snapshot
+	channel  []string
+	done     chan struct{}
+}
+
+// TakeSnapshot inicia um snapshot distribuído e aguarda o retorno do marcador
+func (m *Machine) TakeSnapshot(ctx context.Context) (*Snapshot, error) {
+	var id string
+	var state *snapshotState
+	var err error
+	m.do(func() {
+		m.snapshotCounter++
+		id = fmt.Sprintf("%s-%d", m.config.MachineName, m.snapshotCounter)
+		state = &snapshotState{
+			snapshot: &Snapshot{ID: id, Initiator: m.config.MachineName, Started: m.systemNow()},
+			done:     make(chan struct{}),
+		}
+
+		// Grava o estado local e envia o marcador no mesmo evento, sem outro envio entre os dois
+		stations := []StationSnapshot{m.recordLocalState()}
+		if err = m.sendMarker(id, m.config.MachineName, stations); err != nil {
+			return
+		}
+		m.snapshots[id] = state
+		m.logf("Snapshot %s iniciado", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-state.done:
+		return state.snapshot, nil
+	case <-ctx.Done():
+		m.do(func() {
+			delete(m.snapshots, id)
+		})
+		return nil, ctx.Err()
+	}
+}
+
+// WriteFile grava o snapshot em um arquivo JSON
+func (s *Snapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao codificar snapshot: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("erro ao gravar snapshot: %v", err)
+	}
+	return nil
+}
+
+// handleMarker processa um marcador de snapshot recebido
+// Executado no laço de eventos
+func (m *Machine) handleMarker(data string) {
+	id, initiator, stations, err := parseMarker(data)
+	if err != nil {
+		m.errorf("Erro ao parsear marcador de snapshot: %v", err)
+		return
+	}
+
+	if initiator != m.config.MachineName {
+		// Primeira (e única) chegada do marcador: grava o estado e repassa imediatamente
+		m.logf("Marcador do snapshot %s recebido, gravando estado", id)
+		stations = append(stations, m.recordLocalState())
+		if err := m.sendMarker(id, initiator, stations); err != nil {
+			m.errorf("Erro ao repassar marcador: %v", err)
+		}
+		return
+	}
+
+	// O marcador retornou ao iniciador: o snapshot está completo
+	state, ok := m.snapshots[id]
+	if !ok {
+		m.warnf("Marcador de snapshot desconhecido ou cancelado: %s", id)
+		return
+	}
+	delete(m.snapshots, id)
+
+	state.snapshot.Stations = stations
+	state.snapshot.InFlight = append([]string{}, state.channel...)
+	state.snapshot.Completed = m.systemNow()
+	close(state.done)
+
+	m.logf("Snapshot %s concluído: %d estações, %d quadros em trânsito",
+		id, len(stations), len(state.snapshot.InFlight))
+}
+
+// recordChannel grava um quadro recebido no canal de entrada dos snapshots em andamento
+// Executado no laço de eventos
+func (m *Machine) recordChannel(data string) {
+	for _, state := range m.snapshots {
+		state.channel = append(state.channel, data)
+	}
+}
+
+// recordLocalState grava o estado atual desta estação
+// Executado no laço de eventos
+func (m *Machine) recordLocalState() StationSnapshot {
+	station := StationSnapshot{
+		Machine:  m.config.MachineName,
+		Recorded: m.Now(),
+		Status:   m.currentStatus(),
+		Queue:    m.queue.GetAll(),
+		Inbox:    m.inbox.GetAll(),
+		LockHeld: m.lockHeld,
+		OrderSeq: m.orderSeq,
+	}
+	if m.waitingForData && m.currentDataMsg != nil {
+		station.WaitingFor = m.currentDataMsg.RawData
+	}
+	if len(m.groups) > 0 {
+		station.Groups = m.groupList()
+	}
+	if m.mailbox != nil {
+		station.MailboxEntries = m.mailbox.Size()
+	}
+	return station
+}
+
+// sendMarker envia o marcador com os estados gravados até agora
+// Formato: 4000;<id>:<iniciador>:<estados em JSON>
+func (m *Machine) sendMarker(id, initiator string, stations []StationSnapshot) error {
+	encoded, err := json.Marshal(stations)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar estados do snapshot: %v", err)
+	}
+	return m.sendPacket(fmt.Sprintf("%s;%s:%s:%s", message.SnapshotPacket, id, initiator, encoded))
+}
+
+// parseMarker analisa um marcador de snapshot
+func parseMarker(data string) (string, string, []StationSnapshot, error) {
+	_, body, found := strings.Cut(data, ";")
+	if !found {
+		return "", "", nil, fmt.Errorf("marcador sem corpo")
+	}
+	parts := strings.SplitN(body, ":", 3)
+	if len(parts) != 3 {
+		return "", "", nil, fmt.Errorf("formato de marcador inválido: esperado 3 partes, obtido %d", len(parts))
+	}
+
+	var stations []StationSnapshot
+	if err := json.Unmarshal([]byte(parts[2]), &stations); err != nil {
+		return "", "", nil, fmt.Errorf("estados do snapshot inválidos: %v", err)
+	}
+	return parts[0], parts[1], stations, nil
+}
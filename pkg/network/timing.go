@@ -0,0 +1,76 @@
+package network
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/metrics"
+)
+
+// TimingStats reúne as estatísticas de tempo das mensagens enviadas e do token
+// Os tempos são em segundos; cada resumo usa as observações mais recentes (metrics.DefaultSummaryWindow)
+type TimingStats struct {
+	QueueDelay    metrics.SummarySnapshot `json:"espera_fila"`    // Do enfileiramento à primeira transmissão
+	TimeToACK     metrics.SummarySnapshot `json:"tempo_ack"`      // Da primeira transmissão à confirmação (ACK)
+	Retries       metrics.SummarySnapshot `json:"retransmissoes"` // Retransmissões por NAK de cada mensagem entregue
+	TokenRotation metrics.SummarySnapshot `json:"volta_token"`    // Entre chegadas consecutivas do token
+}
+
+// GetTimingStats retorna as estatísticas de tempo da máquina
+func (m *Machine) GetTimingStats() TimingStats {
+	return TimingStats{
+		QueueDelay:    m.queueDelay.Snapshot(),
+		TimeToACK:     m.ackDelay.Snapshot(),
+		Retries:       m.deliveryRetries.Snapshot(),
+		TokenRotation: m.rotationStats.Snapshot(),
+	}
+}
+
+// WriteCSV grava as estatísticas em CSV, uma linha por métrica
+// Colunas: metrica, unidade, total, amostras, min, media, p50, p95, max
+func (t TimingStats) WriteCSV(w io.Writer) error {
+	rows := []struct {
+		name, unit string
+		summary    metrics.SummarySnapshot
+	}{
+		{"espera_fila", "s", t.QueueDelay},
+		{"tempo_ack", "s", t.TimeToACK},
+		{"retransmissoes", "mensagem", t.Retries},
+		{"volta_token", "s", t.TokenRotation},
+	}
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"metrica", "unidade", "total", "amostras", "min", "media", "p50", "p95", "max"})
+	for _, row := range rows {
+		s := row.summary
+		out.Write([]string{
+			row.name, row.unit,
+			strconv.FormatUint(s.Count, 10), strconv.Itoa(s.Samples),
+			formatStat(s.Min), formatStat(s.Avg), formatStat(s.P50), formatStat(s.P95), formatStat(s.Max),
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("erro ao gravar estatísticas: %v", err)
+	}
+	return nil
+}
+
+// formatStat formata um valor das estatísticas para o CSV (microssegundos de resolução)
+func formatStat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
+
+// observeTransmission registra a espera na fila quando a primeira mensagem é transmitida pela primeira vez
+// Executado no laço de eventos
+func (m *Machine) observeTransmission(msg *message.QueuedMessage) {
+	if !msg.Sent.IsZero() {
+		return
+	}
+	now := m.systemNow()
+	m.queue.SetFirstMessageSent(now)
+	m.queueDelay.Observe(now.Sub(msg.Timestamp).Seconds())
+}
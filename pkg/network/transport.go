@@ -0,0 +1,73 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Transport é o meio pelo qual a máquina troca quadros com as vizinhas
+// O padrão é UDP; outras implementações permitem rodar a máquina em memória, em testes ou simulações
+type Transport interface {
+	// ReadFrom aguarda o próximo quadro até o prazo informado e retorna o endereço de quem o enviou
+	// Ao fim do prazo, retorna um erro que satisfaz errors.Is(err, os.ErrDeadlineExceeded)
+	// Depois de Close, retorna um erro que satisfaz errors.Is(err, net.ErrClosed)
+	ReadFrom(buffer []byte, deadline time.Time) (int, string, error)
+
+	// WriteTo envia um quadro para o endereço informado (IP:porta)
+	WriteTo(data []byte, address string) error
+
+	// Close encerra o transporte, desbloqueando ReadFrom
+	Close() error
+}
+
+// UDPTransport troca os quadros por datagramas UDP
+type UDPTransport struct {
+	conn *net.UDPConn
+}
+
+// NewUDPTransport cria o transporte UDP escutando na porta informada
+func NewUDPTransport(port int) (*UDPTransport, error) {
+	// Configura o endereço UDP para escuta
+	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver endereço: %v", err)
+	}
+
+	// Cria o socket UDP para comunicação
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar socket UDP: %v", err)
+	}
+	return &UDPTransport{conn: conn}, nil
+}
+
+// ReadFrom aguarda o próximo datagrama até o prazo informado
+func (t *UDPTransport) ReadFrom(buffer []byte, deadline time.Time) (int, string, error) {
+	t.conn.SetReadDeadline(deadline)
+	n, addr, err := t.conn.ReadFromUDP(buffer)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, addr.String(), nil
+}
+
+// WriteTo envia um datagrama para o endereço informado
+func (t *UDPTransport) WriteTo(data []byte, address string) error {
+	// Resolve o endereço UDP do destino
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return fmt.Errorf("erro ao resolver endereço: %v", err)
+	}
+
+	// Envia os dados via UDP
+	if _, err := t.conn.WriteToUDP(data, addr); err != nil {
+		return fmt.Errorf("erro ao enviar pacote: %v", err)
+	}
+	return nil
+}
+
+// Close fecha o socket UDP
+func (t *UDPTransport) Close() error {
+	return t.conn.Close()
+}
@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/message"
+)
+
+// Sender envia um quadro para um endereço (IP:porta)
+// network.Transport satisfaz esta interface
+type Sender interface {
+	WriteTo(data []byte, address string) error
+}
+
+// Frame é um quadro a reinjetar
+type Frame struct {
+	Offset  time.Duration  // Tempo desde o primeiro quadro da reprodução, na captura original
+	Address string         // Endereço para onde o quadro é enviado
+	Packet  capture.Packet // Quadro capturado
+}
+
+// Selection define quais quadros da captura são reinjetados e para onde
+type Selection struct {
+	// Station reproduz apenas os quadros recebidos por esta estação, enviados a Target,
+	// para que ela receba exatamente as mesmas entradas da captura
+	// Vazio: reproduz apenas os quadros originados pelas estações das capturas (geração do token
+	// e primeira transmissão de cada mensagem pela origem), cada um ao seu destino original;
+	// repasses, respostas, passagens do token e retransmissões são refeitos pelo próprio anel
+	Station string
+
+	// Target é o endereço que recebe todos os quadros (obrigatório com Station)
+	Target string
+
+	// Addresses troca os endereços originais dos destinos (ex: a captura foi feita em outro host)
+	Addresses map[string]string
+
+	// Types limita os tipos de pacote reproduzidos (ex: 2000); vazio reproduz todos
+	Types map[string]bool
+}
+
+// Select escolhe os quadros a reinjetar, na ordem dos horários da captura
+func Select(packets []capture.Packet, sel Selection) ([]Frame, error) {
+	if sel.Station != "" && sel.Target == "" {
+		return nil, fmt.Errorf("endereço de destino obrigatório para reproduzir as entradas de %s", sel.Station)
+	}
+
+	var frames []Frame
+	var start time.Time
+	origins := make(originTracker)
+	for _, p := range packets {
+		// Os quadros recebidos atualizam o estado das estações mesmo fora dos tipos escolhidos
+		originated := sel.Station == "" && origins.originates(p)
+		if len(sel.Types) > 0 && !sel.Types[message.PacketType(p.Frame)] {
+			continue
+		}
+
+		var address string
+		if sel.Station != "" {
+			if p.Station != sel.Station || p.Direction != capture.Received {
+				continue
+			}
+			address = sel.Target
+		} else {
+			if !originated {
+				continue
+			}
+			address = p.Peer
+			if mapped, ok := sel.Addresses[address]; ok {
+				address = mapped
+			}
+			if sel.Target != "" {
+				address = sel.Target
+			}
+		}
+
+		if len(frames) == 0 {
+			start = p.Time
+		}
+		frames = append(frames, Frame{Offset: p.Time.Sub(start), Address: address, Packet: p})
+	}
+	return frames, nil
+}
+
+// originTracker acompanha, pelos quadros capturados de cada estação, o token e as
+// mensagens em circulação, para distinguir os quadros que a estação originou
+type originTracker map[string]*originState
+
+// originState é o estado de uma estação reconstruído da sua captura
+type originState struct {
+	hasToken bool   // A estação recebeu o token e ainda não o passou
+	retry    string // Mensagem que voltou sem confirmação e será retransmitida (chave de frameKey)
+}
+
+// originates indica se o quadro foi enviado e originado pela estação que o capturou:
+// o token gerado por ela (enviado sem ter sido recebido) ou a primeira transmissão de uma
+// mensagem da sua fila. Os quadros recebidos apenas atualizam o estado da estação
+func (t originTracker) originates(p capture.Packet) bool {
+	state, ok := t[p.Station]
+	if !ok {
+		state = &originState{}
+		t[p.Station] = state
+	}
+
+	switch {
+	case message.IsTokenPacket(p.Frame):
+		if p.Direction == capture.Received {
+			state.hasToken = true
+			return false
+		}
+		generated := !state.hasToken
+		state.hasToken = false
+		return generated
+
+	case message.PacketType(p.Frame) == message.DataPacket:
+		dataMsg, err := message.ParseDataPacket(p.Frame)
+		if err != nil || dataMsg.Origin != p.Station {
+			return false
+		}
+		key := frameKey(dataMsg)
+		if p.Direction == capture.Received {
+			// O quadro voltou à origem: sem a confirmação de todos os destinos, será retransmitido
+			state.retry = ""
+			if !confirmed(dataMsg) {
+				state.retry = key
+			}
+			return false
+		}
+		// O pedido à caixa postal segue o retorno de uma mensagem sem destino e é refeito pela origem
+		return dataMsg.Control != message.ControlStore && key != state.retry
+	}
+	return false
+}
+
+// frameKey identifica uma mensagem da fila nas suas transmissões (o controle e o CRC mudam)
+func frameKey(dataMsg *message.DataMessage) string {
+	return fmt.Sprintf("%s|%s|%d|%s", dataMsg.Origin, dataMsg.Destination, dataMsg.Seq, dataMsg.Message)
+}
+
+// confirmed indica se o quadro que voltou à origem encerra a mensagem (não haverá retransmissão)
+// Broadcast e multicast são retransmitidos até todas as estações confirmarem; as demais
+// mensagens, após NAK ou BUSY
+func confirmed(dataMsg *message.DataMessage) bool {
+	if dataMsg.HasReceipts() {
+		for _, receipt := range dataMsg.Receipts() {
+			if receipt.Status != message.ControlACK {
+				return false
+			}
+		}
+		return true
+	}
+	return dataMsg.Control != message.ControlNAK && dataMsg.Control != message.ControlBusy
+}
+
+// Run reinjeta os quadros respeitando os intervalos originais divididos por speed
+// (2 reproduz duas vezes mais rápido; 0 envia sem espera)
+// A função sent, se informada, é chamada após cada envio
+// Retorna o erro do contexto se a reprodução for interrompida
+func Run(ctx context.Context, sender Sender, frames []Frame, speed float64, sent func(Frame)) error {
+	if speed < 0 {
+		return fmt.Errorf("velocidade não pode ser negativa: %v", speed)
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for _, frame := range frames {
+		if speed > 0 {
+			wait := time.Until(start.Add(time.Duration(float64(frame.Offset) / speed)))
+			if wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := sender.WriteTo([]byte(frame.Packet.Frame), frame.Address); err != nil {
+			return err
+		}
+		if sent != nil {
+			sent(frame)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/message"
+)
+
+// TestSelectOriginatedFrames verifica que, sem estação escolhida, apenas o token gerado e a primeira
+// transmissão de cada mensagem são reinjetados (não os repasses, passagens do token e retransmissões)
+func TestSelectOriginatedFrames(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	var packets []capture.Packet
+	add := func(station string, direction capture.Direction, peer, frame string) {
+		packets = append(packets, capture.Packet{
+			Time:      start.Add(time.Duration(len(packets)) * time.Millisecond),
+			Station:   station,
+			Direction: direction,
+			Peer:      peer,
+			Frame:     frame,
+		})
+	}
+	data := func(control string) string {
+		dataMsg := message.CreateDataPacket("Alice", "Bob", "ola")
+		dataMsg.SetControl(control)
+		return dataMsg.RawData
+	}
+	token := message.CreateTokenPacket()
+
+	add("Alice", capture.Sent, "bob:1", token)                                 // Token gerado
+	add("Bob", capture.Received, "alice:1", token)                             // Token chega a Bob
+	add("Bob", capture.Sent, "carol:1", token)                                 // Passagem do token
+	add("Alice", capture.Received, "carol:1", token)                           // Token volta a Alice
+	add("Alice", capture.Sent, "bob:1", data(message.ControlMachineNotExists)) // Primeira transmissão
+	add("Bob", capture.Sent, "carol:1", data(message.ControlNAK))              // Resposta de Bob
+	add("Alice", capture.Received, "carol:1", data(message.ControlNAK))        // Retorno com NAK
+	add("Alice", capture.Sent, "bob:1", token)                                 // Passagem do token
+	add("Alice", capture.Received, "carol:1", token)                           // Token volta a Alice
+	add("Alice", capture.Sent, "bob:1", data(message.ControlMachineNotExists)) // Retransmissão
+	add("Alice", capture.Received, "carol:1", data(message.ControlACK))        // Retorno com ACK
+	add("Alice", capture.Sent, "bob:1", token)                                 // Passagem do token
+	add("Alice", capture.Received, "carol:1", token)                           // Token volta a Alice
+	add("Alice", capture.Sent, "bob:1", data(message.ControlMachineNotExists)) // Nova mensagem igual
+
+	frames, err := Select(packets, Selection{Addresses: map[string]string{"bob:1": "10.0.0.2:6001"}})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	want := []int{0, 4, 13}
+	if len(frames) != len(want) {
+		t.Fatalf("%d quadros selecionados, esperava %d: %+v", len(frames), len(want), frames)
+	}
+	for i, index := range want {
+		if frames[i].Packet != packets[index] {
+			t.Errorf("quadro %d: %+v, esperava o pacote %d", i, frames[i].Packet, index)
+		}
+		if frames[i].Address != "10.0.0.2:6001" {
+			t.Errorf("quadro %d enviado a %s", i, frames[i].Address)
+		}
+		if offset := packets[index].Time.Sub(start); frames[i].Offset != offset {
+			t.Errorf("quadro %d no instante %v, esperava %v", i, frames[i].Offset, offset)
+		}
+	}
+}
+
+// TestSelectStationInputs verifica que, com uma estação escolhida, todos os quadros recebidos por ela são reinjetados
+func TestSelectStationInputs(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	token := message.CreateTokenPacket()
+	packets := []capture.Packet{
+		{Time: start, Station: "Bob", Direction: capture.Received, Peer: "alice:1", Frame: token},
+		{Time: start.Add(time.Millisecond), Station: "Bob", Direction: capture.Sent, Peer: "carol:1", Frame: token},
+		{Time: start.Add(2 * time.Millisecond), Station: "Bob", Direction: capture.Received, Peer: "alice:1", Frame: token},
+	}
+
+	if _, err := Select(packets, Selection{Station: "Bob"}); err == nil {
+		t.Errorf("Select deveria exigir o endereço de destino")
+	}
+	frames, err := Select(packets, Selection{Station: "Bob", Target: "127.0.0.1:6001"})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(frames) != 2 || frames[1].Offset != 2*time.Millisecond || frames[1].Address != "127.0.0.1:6001" {
+		t.Errorf("quadros selecionados: %+v", frames)
+	}
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Epoch é o horário virtual do início de toda simulação (fixo, para que as execuções se repitam)
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Simulator é o escalonador de eventos discretos: executa os eventos agendados na ordem do
+// horário virtual, avançando o relógio de um evento para o próximo sem esperar
+// Eventos no mesmo horário são executados na ordem em que foram agendados
+// Implementa network.Scheduler; não é seguro para uso concorrente
+type Simulator struct {
+	now       time.Time
+	events    eventQueue
+	seq       uint64 // Ordem de agendamento, para desempatar eventos no mesmo horário
+	processed uint64 // Eventos executados
+}
+
+// event é uma função agendada para um horário virtual
+type event struct {
+	at        time.Time
+	seq       uint64
+	fn        func()
+	cancelled bool
+}
+
+// NewSimulator cria um escalonador com o relógio em Epoch
+func NewSimulator() *Simulator {
+	return &Simulator{now: Epoch}
+}
+
+// Now retorna o horário virtual atual
+func (s *Simulator) Now() time.Time {
+	return s.now
+}
+
+// Elapsed retorna o tempo virtual desde o início da simulação
+func (s *Simulator) Elapsed() time.Duration {
+	return s.now.Sub(Epoch)
+}
+
+// Processed retorna o número de eventos executados
+func (s *Simulator) Processed() uint64 {
+	return s.processed
+}
+
+// Pending retorna o número de eventos agendados (inclusive os cancelados ainda não descartados)
+func (s *Simulator) Pending() int {
+	return len(s.events)
+}
+
+// AfterFunc agenda fn para daqui a d no tempo virtual e retorna a função que cancela o agendamento
+// Um atraso negativo é tratado como zero
+func (s *Simulator) AfterFunc(d time.Duration, fn func()) func() {
+	if d < 0 {
+		d = 0
+	}
+	s.seq++
+	e := &event{at: s.now.Add(d), seq: s.seq, fn: fn}
+	heap.Push(&s.events, e)
+	return func() { e.cancelled = true }
+}
+
+// Step executa o próximo evento, avançando o relógio até ele
+// Retorna false se não houver eventos agendados
+func (s *Simulator) Step() bool {
+	for len(s.events) > 0 {
+		e := heap.Pop(&s.events).(*event)
+		if e.cancelled {
+			continue
+		}
+		s.now = e.at
+		s.processed++
+		e.fn()
+		return true
+	}
+	return false
+}
+
+// RunUntil executa os eventos até o horário virtual informado e deixa o relógio nele
+// Retorna false se os eventos acabaram antes
+func (s *Simulator) RunUntil(t time.Time) bool {
+	for len(s.events) > 0 {
+		if s.events[0].cancelled {
+			heap.Pop(&s.events)
+			continue
+		}
+		if s.events[0].at.After(t) {
+			break
+		}
+		s.Step()
+	}
+	if s.now.Before(t) {
+		s.now = t
+	}
+	return len(s.events) > 0
+}
+
+// RunFor executa os eventos pelo tempo virtual informado a partir de agora
+func (s *Simulator) RunFor(d time.Duration) bool {
+	return s.RunUntil(s.now.Add(d))
+}
+
+// eventQueue é a fila de prioridade dos eventos (heap por horário e ordem de agendamento)
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,117 @@
+package sim
+
+import (
+	"strings"
+	"time"
+
+	"ring-network/pkg/message"
+)
+
+// lamportDigits estima o tamanho do relógio de Lamport no cabeçalho ao longo de uma simulação
+const lamportDigits = 7
+
+// Model reúne os tempos do anel usados nas fórmulas analíticas do anel com token
+//
+// Cada estação retém o token por Hold, transmite no máximo uma mensagem e só passa o token
+// quando o quadro volta (o protocolo do projeto). Como cada estação recebe o quadro inteiro
+// antes de repassá-lo, a volta do quadro custa Stations × (transmissão + propagação).
+// Para as fórmulas de filas, o anel é um sistema de polling simétrico com serviço limitado
+// a uma mensagem por visita: o serviço é a volta do quadro (Service) e o tempo de troca
+// entre estações é a posse e a passagem do token (Walk, somado sobre o anel).
+type Model struct {
+	Stations    int
+	Frame       time.Duration // Transmissão de um quadro de dados em um enlace (média entre ida e resposta)
+	Token       time.Duration // Transmissão do token em um enlace
+	Propagation time.Duration // Propagação em um enlace
+	Hold        time.Duration // Posse do token em cada estação
+	Service     time.Duration // Da transmissão ao retorno do quadro à origem (b)
+	Walk        time.Duration // Volta do token sem mensagens (s)
+}
+
+// NewModel calcula os tempos do anel para mensagens com o conteúdo do tamanho informado
+func NewModel(cfg Config, size int) Model {
+	header := len("|lc=") + lamportDigits
+	content := strings.Repeat("x", size)
+	first := StationName(0)
+
+	// Até o destino o quadro leva o controle inicial; dali até a origem, a resposta (ACK)
+	// Com destinos uniformes, em média metade dos enlaces leva cada um
+	request := message.CreateDataPacket(first, first, content)
+	sent := len(request.RawData) + header
+	request.SetControl(message.ControlACK)
+	replied := len(request.RawData) + header
+
+	n := time.Duration(cfg.Stations)
+	m := Model{
+		Stations:    cfg.Stations,
+		Frame:       (cfg.TransmissionTime(sent) + cfg.TransmissionTime(replied)) / 2,
+		Token:       cfg.TransmissionTime(len(message.CreateTokenPacket()) + header),
+		Propagation: cfg.Propagation,
+		Hold:        cfg.TokenHold,
+	}
+	m.Service = n * (m.Frame + m.Propagation)
+	m.Walk = n * (m.Hold + m.Token + m.Propagation)
+	return m
+}
+
+// SaturationRate é a taxa por estação (mensagens por segundo) em que o anel satura:
+// toda visita do token leva uma mensagem, e cada volta dura Walk + Stations × Service
+func (m Model) SaturationRate() float64 {
+	return 1 / (m.Walk + time.Duration(m.Stations)*m.Service).Seconds()
+}
+
+// Utilization é a fração do tempo em que uma mensagem circula pelo anel (ρ = N λ b), com a taxa por estação
+func (m Model) Utilization(rate float64) float64 {
+	return float64(m.Stations) * rate * m.Service.Seconds()
+}
+
+// Throughput é a vazão normalizada S com a taxa por estação: a fração do tempo de um enlace
+// ocupada com quadros de dados novos, limitada pela saturação
+func (m Model) Throughput(rate float64) float64 {
+	return float64(m.Stations) * min(rate, m.SaturationRate()) * m.Frame.Seconds()
+}
+
+// MaxThroughput é a vazão normalizada do protocolo saturado (uma mensagem por visita do token)
+func (m Model) MaxThroughput() float64 {
+	return m.Throughput(m.SaturationRate())
+}
+
+// A é a razão entre a latência do anel (propagação somada) e a transmissão de um quadro
+func (m Model) A() float64 {
+	return float64(time.Duration(m.Stations)*m.Propagation) / float64(m.Frame)
+}
+
+// TextbookMaxThroughput é a vazão máxima do anel com token clássico (Stallings), em que as estações
+// repetem os bits sem armazenar o quadro: 1/(1 + a/N) com a < 1 e 1/(a(1 + 1/N)) com a ≥ 1
+func (m Model) TextbookMaxThroughput() float64 {
+	a, n := m.A(), float64(m.Stations)
+	if a < 1 {
+		return 1 / (1 + a/n)
+	}
+	return 1 / (a * (1 + 1/n))
+}
+
+// Wait é a espera média na fila (do enfileiramento à transmissão) com a taxa por estação, pela
+// fórmula do polling simétrico com serviço limitado e tempos constantes:
+//
+//	W = (N λ b² + s (1 + ρ/N)) / (2 (1 − ρ − λ s))
+//
+// Retorna false se o anel não é estável nessa taxa (ρ + λ s ≥ 1)
+func (m Model) Wait(rate float64) (time.Duration, bool) {
+	n := float64(m.Stations)
+	b, s := m.Service.Seconds(), m.Walk.Seconds()
+	rho := m.Utilization(rate)
+	free := 1 - rho - rate*s
+	if free <= 0 {
+		return 0, false
+	}
+	wait := (n*rate*b*b + s*(1+rho/n)) / (2 * free)
+	return time.Duration(wait * float64(time.Second)), true
+}
+
+// Delay é o atraso médio do enfileiramento à confirmação (espera + volta do quadro)
+// Retorna false se o anel não é estável nessa taxa
+func (m Model) Delay(rate float64) (time.Duration, bool) {
+	wait, ok := m.Wait(rate)
+	return wait + m.Service, ok
+}
@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+
+	"ring-network/pkg/capture"
+	"ring-network/pkg/replay"
+)
+
+// Injector entrega quadros às estações do anel simulado, como se chegassem pela rede
+// Satisfaz replay.Sender: o endereço é o nome da estação, e o quadro chega pelo Deliver
+// da máquina no instante atual do simulador
+type Injector struct {
+	ring *Ring
+	from string // Remetente informado às máquinas
+}
+
+// Injector retorna um injetor de quadros com o remetente informado
+func (r *Ring) Injector(from string) *Injector {
+	return &Injector{ring: r, from: from}
+}
+
+// WriteTo agenda a chegada do quadro à estação no instante atual do simulador
+func (i *Injector) WriteTo(data []byte, address string) error {
+	return i.ring.inject(data, address, i.from, 0)
+}
+
+// Replay agenda a chegada dos quadros de replay.Select às estações, nos intervalos originais
+// divididos por speed e contados a partir do instante atual do simulador (0 entrega todos agora)
+// O endereço de cada quadro deve ser o nome de uma estação (use replay.Selection.Addresses
+// para trocar os endereços IP:porta da captura). A função sent, se informada, é chamada a cada entrega
+// Retorna o instante virtual da última entrega; o simulador deve ser executado até lá (RunUntil)
+func (r *Ring) Replay(frames []replay.Frame, speed float64, sent func(replay.Frame)) (time.Time, error) {
+	if speed < 0 {
+		return time.Time{}, fmt.Errorf("velocidade não pode ser negativa: %v", speed)
+	}
+
+	for _, frame := range frames {
+		if r.Station(frame.Address) == nil {
+			return time.Time{}, fmt.Errorf("estação desconhecida no anel simulado: %s", frame.Address)
+		}
+	}
+
+	last := r.Sim.Now()
+	for _, frame := range frames {
+		var delay time.Duration
+		if speed > 0 {
+			delay = time.Duration(float64(frame.Offset) / speed)
+		}
+
+		// O remetente é a estação que enviou o quadro na captura
+		from := frame.Packet.Station
+		if frame.Packet.Direction == capture.Received {
+			from = frame.Packet.Peer
+		}
+		if err := r.inject([]byte(frame.Packet.Frame), frame.Address, from, delay); err != nil {
+			return time.Time{}, err
+		}
+		if sent != nil {
+			r.Sim.AfterFunc(delay, func() { sent(frame) })
+		}
+		if at := r.Sim.Now().Add(delay); at.After(last) {
+			last = at
+		}
+	}
+	return last, nil
+}
+
+// inject agenda a chegada de um quadro à estação após o atraso informado
+func (r *Ring) inject(data []byte, address, from string, delay time.Duration) error {
+	station := r.Station(address)
+	if station == nil {
+		return fmt.Errorf("estação desconhecida no anel simulado: %s", address)
+	}
+	frame := append([]byte(nil), data...)
+	r.Sim.AfterFunc(delay, func() {
+		station.Machine.Deliver(frame, from)
+	})
+	return nil
+}
@@ -0,0 +1,105 @@
+package sim
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteReport escreve em texto o modelo do anel e a curva de cada carga, com a fórmula ao lado da medida
+func WriteReport(w io.Writer, cfg Config, model Model, points []Point) {
+	fmt.Fprintf(w, "=== Anel simulado: %d estações, enlaces de %s com propagação de %v, posse do token %v ===\n",
+		cfg.Stations, formatBandwidth(cfg.Bandwidth), cfg.Propagation, cfg.TokenHold)
+	fmt.Fprintf(w, "Quadro de dados: %v por enlace | token: %v | volta do quadro (b): %v | volta do token vazio (s): %v\n",
+		model.Frame, model.Token, model.Service, model.Walk)
+	fmt.Fprintf(w, "Saturação: %.4f msg/s por estação (%.2f msg/s no anel)\n",
+		model.SaturationRate(), model.SaturationRate()*float64(cfg.Stations))
+	fmt.Fprintf(w, "Vazão normalizada máxima: %.4f neste protocolo | %.4f no anel clássico (a = %.3f)\n",
+		model.MaxThroughput(), model.TextbookMaxThroughput(), model.A())
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%6s %10s %8s %8s %8s %7s %12s %12s %12s %12s %9s\n",
+		"carga", "msg/s", "S", "S fórm.", "ρ", "enlace", "espera", "espera fórm.", "atraso", "atraso fórm.", "entregues")
+	for _, p := range points {
+		modelWait, modelDelay := "instável", "instável"
+		if p.Stable {
+			modelWait, modelDelay = formatDuration(p.ModelWait), formatDuration(p.ModelDelay)
+		}
+		fmt.Fprintf(w, "%6.2f %10.3f %8.5f %8.5f %8.4f %6.1f%% %12s %12s %12s %12s %9s\n",
+			p.Load, p.Throughput, p.Normalized, model.Throughput(p.Rate), p.Utilization, 100*p.LinkBusy,
+			formatSeconds(p.Wait.Avg), modelWait, formatSeconds(p.Delay.Avg), modelDelay,
+			fmt.Sprintf("%d/%d", p.Delivered, p.Offered))
+	}
+}
+
+// WriteCSV grava as curvas em CSV, uma linha por carga
+// Os tempos são em segundos; as colunas _formula vêm do modelo (vazias se o anel for instável)
+func WriteCSV(w io.Writer, model Model, points []Point) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"carga", "taxa_estacao", "geradas", "recusadas", "entregues", "pendentes",
+		"vazao", "vazao_normalizada", "vazao_normalizada_formula", "utilizacao", "utilizacao_formula", "ocupacao_enlaces",
+		"espera_media", "espera_p95", "espera_formula", "atraso_medio", "atraso_p95", "atraso_formula",
+		"volta_token_media", "tempo_simulado", "eventos",
+	})
+	for _, p := range points {
+		modelWait, modelDelay := "", ""
+		if p.Stable {
+			modelWait, modelDelay = formatFloat(p.ModelWait.Seconds()), formatFloat(p.ModelDelay.Seconds())
+		}
+		out.Write([]string{
+			formatFloat(p.Load), formatFloat(p.Rate),
+			strconv.Itoa(p.Offered), strconv.Itoa(p.Rejected), strconv.Itoa(p.Delivered), strconv.Itoa(p.Pending),
+			formatFloat(p.Throughput), formatFloat(p.Normalized), formatFloat(model.Throughput(p.Rate)),
+			formatFloat(p.Utilization), formatFloat(model.Utilization(p.Rate)), formatFloat(p.LinkBusy),
+			formatFloat(p.Wait.Avg), formatFloat(p.Wait.P95), modelWait,
+			formatFloat(p.Delay.Avg), formatFloat(p.Delay.P95), modelDelay,
+			formatFloat(p.Rotation.Avg), formatFloat(p.Simulated.Seconds()), strconv.FormatUint(p.Events, 10),
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("erro ao gravar curvas: %v", err)
+	}
+	return nil
+}
+
+// formatFloat formata um valor para o CSV
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', 6, 64)
+}
+
+// formatSeconds formata um tempo em segundos
+func formatSeconds(seconds float64) string {
+	return formatDuration(time.Duration(seconds * float64(time.Second)))
+}
+
+// formatDuration arredonda um tempo para exibição (quatro algarismos significativos)
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 10*time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= 10*time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(10 * time.Nanosecond).String()
+}
+
+// formatBandwidth formata uma taxa de transmissão em bits por segundo
+func formatBandwidth(bps float64) string {
+	switch {
+	case bps >= 1e9:
+		return fmt.Sprintf("%g Gbit/s", bps/1e9)
+	case bps >= 1e6:
+		return fmt.Sprintf("%g Mbit/s", bps/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%g kbit/s", bps/1e3)
+	}
+	return fmt.Sprintf("%g bit/s", bps)
+}
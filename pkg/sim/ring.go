@@ -0,0 +1,276 @@
+package sim
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"time"
+
+	"ring-network/pkg/config"
+	"ring-network/pkg/network"
+)
+
+// Valores padrão do anel simulado
+const (
+	DefaultStations      = 200
+	DefaultBandwidth     = 10e6 // 10 Mbit/s
+	DefaultPropagation   = 5 * time.Microsecond
+	DefaultQueueCapacity = 1000
+	DefaultReceiveBuffer = 10
+)
+
+// Config descreve o anel simulado
+type Config struct {
+	Stations         int           // Número de estações
+	Bandwidth        float64       // Taxa de transmissão de cada enlace, em bits por segundo
+	Propagation      time.Duration // Atraso de propagação de cada enlace
+	TokenHold        time.Duration // Tempo de posse do token antes de transmitir ou passá-lo (tempo_token)
+	QueueCapacity    int           // Capacidade da fila de envio de cada estação
+	ErrorProbability float64       // Probabilidade de erro introduzido em cada transmissão
+	Seed             int64         // Semente dos sorteios (erros e tráfego)
+	Names            []string      // Nomes das estações, na ordem do anel (vazio = s001, s002, ...)
+	Logger           *slog.Logger  // Recebe o log das máquinas (nil = descarta)
+}
+
+// DefaultConfig retorna o anel padrão: 200 estações, enlaces de 10 Mbit/s com 5µs de propagação, sem erros
+func DefaultConfig() Config {
+	return Config{
+		Stations:      DefaultStations,
+		Bandwidth:     DefaultBandwidth,
+		Propagation:   DefaultPropagation,
+		QueueCapacity: DefaultQueueCapacity,
+		Seed:          1,
+	}
+}
+
+// Validate verifica a configuração do anel
+func (c Config) Validate() error {
+	switch {
+	case c.Stations < 2:
+		return fmt.Errorf("o anel precisa de pelo menos 2 estações: %d", c.Stations)
+	case c.Bandwidth <= 0:
+		return fmt.Errorf("a taxa de transmissão deve ser positiva: %v", c.Bandwidth)
+	case c.Propagation < 0:
+		return fmt.Errorf("o atraso de propagação não pode ser negativo: %v", c.Propagation)
+	case c.TokenHold < 0:
+		return fmt.Errorf("o tempo de posse do token não pode ser negativo: %v", c.TokenHold)
+	case c.QueueCapacity < 1:
+		return fmt.Errorf("a capacidade da fila deve ser positiva: %d", c.QueueCapacity)
+	case c.ErrorProbability < 0 || c.ErrorProbability > 1:
+		return fmt.Errorf("a probabilidade de erro deve estar entre 0 e 1: %v", c.ErrorProbability)
+	case len(c.Names) > 0 && len(c.Names) != c.Stations:
+		return fmt.Errorf("%d nomes informados para %d estações", len(c.Names), c.Stations)
+	}
+	seen := make(map[string]bool, len(c.Names))
+	for _, name := range c.Names {
+		if name == "" || seen[name] {
+			return fmt.Errorf("nome de estação vazio ou repetido: %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// TransmissionTime é o tempo para transmitir um quadro do tamanho informado em um enlace
+func (c Config) TransmissionTime(bytes int) time.Duration {
+	return transmissionTime(bytes, c.Bandwidth)
+}
+
+// transmissionTime é o tempo para transmitir o número de bytes informado na taxa informada (bits/s)
+func transmissionTime(bytes int, bandwidth float64) time.Duration {
+	return time.Duration(float64(bytes*8) / bandwidth * float64(time.Second))
+}
+
+// Ring é um anel de máquinas (network.Machine) ligadas por enlaces simulados, sob um relógio virtual
+type Ring struct {
+	Config   Config
+	Sim      *Simulator
+	Stations []*Station
+}
+
+// Station é uma estação do anel simulado
+type Station struct {
+	Name    string
+	Machine *network.Machine
+	Link    *Link // Enlace até a próxima estação
+}
+
+// NewRing cria o anel: cada estação envia à seguinte pelo seu enlace, e a última à primeira
+// As máquinas são controladas pelo simulador (network.WithScheduler); o anel começa parado (ver Start)
+func NewRing(cfg Config) (*Ring, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Ring{Config: cfg, Sim: NewSimulator(), Stations: make([]*Station, cfg.Stations)}
+	for i := range r.Stations {
+		r.Stations[i] = &Station{Name: StationName(i)}
+		if len(cfg.Names) > 0 {
+			r.Stations[i].Name = cfg.Names[i]
+		}
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	for i, station := range r.Stations {
+		next := r.Stations[(i+1)%len(r.Stations)]
+		station.Link = &Link{sim: r.Sim, from: station.Name, to: next, bandwidth: cfg.Bandwidth, propagation: cfg.Propagation, since: Epoch}
+
+		// Nenhuma estação gera o token nem vigia a circulação: o token inicial é gerado por Start
+		machineConfig := &config.Config{
+			NextMachineAddr:   next.Name,
+			MachineName:       station.Name,
+			TokenTime:         1,
+			ListenPort:        1,
+			ReceiveBufferSize: DefaultReceiveBuffer,
+			MaxLockTime:       config.DefaultMaxLockTime,
+		}
+		machine, err := network.New(
+			network.WithConfig(machineConfig),
+			network.WithTransport(station.Link),
+			network.WithScheduler(r.Sim),
+			network.WithLogger(logger),
+			network.WithTokenTime(cfg.TokenHold),
+			network.WithQueueCapacity(cfg.QueueCapacity),
+			network.WithErrorProbability(cfg.ErrorProbability),
+			network.WithRand(rand.New(rand.NewSource(cfg.Seed+int64(i)))),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar estação %s: %v", station.Name, err)
+		}
+		station.Machine = machine
+
+		// A aplicação de cada estação lê o buffer de recepção assim que uma mensagem chega,
+		// para que os destinos não respondam BUSY
+		machine.OnEvent(func(event network.Event) {
+			if event.Type == network.EventMessageDelivered {
+				r.Sim.AfterFunc(0, func() { machine.ReadInbox() })
+			}
+		})
+	}
+	return r, nil
+}
+
+// StationName retorna o nome da i-ésima estação (s001, s002, ...)
+func StationName(i int) string {
+	return fmt.Sprintf("s%03d", i+1)
+}
+
+// Station retorna a estação com o nome informado (nil se não existe)
+func (r *Ring) Station(name string) *Station {
+	for _, station := range r.Stations {
+		if station.Name == name {
+			return station
+		}
+	}
+	return nil
+}
+
+// Start inicia as máquinas e gera o token na primeira estação
+func (r *Ring) Start() error {
+	if err := r.Begin(); err != nil {
+		return err
+	}
+	return r.Stations[0].Machine.GenerateToken()
+}
+
+// Begin inicia as máquinas sem gerar o token (ex: o token virá de uma reprodução, ver Replay)
+func (r *Ring) Begin() error {
+	for _, station := range r.Stations {
+		if err := station.Machine.Begin(); err != nil {
+			return fmt.Errorf("erro ao iniciar estação %s: %v", station.Name, err)
+		}
+	}
+	return nil
+}
+
+// Stop para as máquinas
+func (r *Ring) Stop() {
+	for _, station := range r.Stations {
+		station.Machine.Stop()
+	}
+}
+
+// LinkUtilization retorna a fração média do tempo em que os enlaces estiveram transmitindo
+// desde a última chamada de ResetLinks
+func (r *Ring) LinkUtilization() float64 {
+	var busy time.Duration
+	var elapsed time.Duration
+	for _, station := range r.Stations {
+		busy += station.Link.busy
+		elapsed += r.Sim.Now().Sub(station.Link.since)
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(busy) / float64(elapsed)
+}
+
+// ResetLinks zera a ocupação medida dos enlaces (ex: ao fim do aquecimento)
+func (r *Ring) ResetLinks() {
+	for _, station := range r.Stations {
+		station.Link.busy = 0
+		station.Link.since = r.Sim.Now()
+	}
+}
+
+// Link é o enlace simulado de uma estação até a próxima, usado como network.Transport
+// Os quadros são transmitidos um de cada vez, na ordem de envio: cada um ocupa o enlace
+// pelo tempo de transmissão (tamanho / taxa) e chega à próxima estação após a propagação
+type Link struct {
+	sim         *Simulator
+	from        string
+	to          *Station
+	bandwidth   float64
+	propagation time.Duration
+	freeAt      time.Time     // Fim da transmissão do último quadro enviado
+	busy        time.Duration // Tempo total de transmissão desde since
+	since       time.Time     // Início da medida de ocupação
+	frames      int           // Quadros transmitidos
+	closed      bool
+}
+
+// ReadFrom não é usado: a máquina controlada pelo simulador recebe os quadros por Deliver
+func (l *Link) ReadFrom(buffer []byte, deadline time.Time) (int, string, error) {
+	return 0, "", os.ErrDeadlineExceeded
+}
+
+// WriteTo agenda a chegada do quadro à próxima estação
+func (l *Link) WriteTo(data []byte, address string) error {
+	if l.closed {
+		return fmt.Errorf("enlace de %s encerrado", l.from)
+	}
+	if address != l.to.Name {
+		return fmt.Errorf("enlace de %s não alcança %s (próxima estação: %s)", l.from, address, l.to.Name)
+	}
+
+	now := l.sim.Now()
+	start := now
+	if l.freeAt.After(start) {
+		start = l.freeAt
+	}
+	transmission := transmissionTime(len(data), l.bandwidth)
+	l.freeAt = start.Add(transmission)
+	l.busy += transmission
+	l.frames++
+
+	frame := append([]byte(nil), data...)
+	l.sim.AfterFunc(l.freeAt.Add(l.propagation).Sub(now), func() {
+		l.to.Machine.Deliver(frame, l.from)
+	})
+	return nil
+}
+
+// Close encerra o enlace
+func (l *Link) Close() error {
+	l.closed = true
+	return nil
+}
+
+// Frames retorna o número de quadros transmitidos pelo enlace
+func (l *Link) Frames() int {
+	return l.frames
+}
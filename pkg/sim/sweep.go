@@ -0,0 +1,355 @@
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/bench"
+	"ring-network/pkg/metrics"
+	"ring-network/pkg/network"
+)
+
+// Valores padrão da varredura de carga
+const (
+	DefaultMessages = 1000 // Mensagens medidas em cada carga
+	DefaultSize     = 64   // Tamanho do conteúdo das mensagens, em bytes
+)
+
+// DefaultLoads são as cargas oferecidas da varredura padrão, em fração da saturação
+var DefaultLoads = []float64{0.2, 0.4, 0.6, 0.8, 0.9, 0.95}
+
+// warmupShare é a fração do tempo de medida simulada antes, para o anel sair do estado vazio
+const warmupShare = 0.1
+
+// Options define a varredura de carga
+type Options struct {
+	Loads    []float64 // Cargas oferecidas, em fração da taxa de saturação (Model.SaturationRate)
+	Messages int       // Mensagens geradas na janela de medida de cada carga
+	Size     int       // Tamanho do conteúdo de cada mensagem, em bytes
+}
+
+// DefaultOptions retorna a varredura padrão
+func DefaultOptions() Options {
+	return Options{Loads: DefaultLoads, Messages: DefaultMessages, Size: DefaultSize}
+}
+
+// Validate verifica as opções da varredura
+func (o Options) Validate() error {
+	if len(o.Loads) == 0 {
+		return fmt.Errorf("nenhuma carga informada")
+	}
+	for _, load := range o.Loads {
+		if load <= 0 {
+			return fmt.Errorf("a carga deve ser positiva: %v", load)
+		}
+	}
+	if o.Messages < 1 {
+		return fmt.Errorf("o número de mensagens deve ser positivo: %d", o.Messages)
+	}
+	if o.Size < 1 {
+		return fmt.Errorf("o tamanho da mensagem deve ser positivo: %d", o.Size)
+	}
+	return nil
+}
+
+// Point é o resultado da simulação em uma carga oferecida
+// Os tempos dos resumos são em segundos
+type Point struct {
+	Load        float64                 // Carga oferecida, em fração da saturação
+	Rate        float64                 // Mensagens por segundo geradas em cada estação
+	Offered     int                     // Mensagens geradas na janela de medida
+	Rejected    int                     // Mensagens recusadas pela fila cheia
+	Delivered   int                     // Mensagens da janela confirmadas (ACK)
+	Pending     int                     // Mensagens da janela não confirmadas ao fim da simulação
+	Throughput  float64                 // Confirmações por segundo no anel, durante a janela
+	Normalized  float64                 // Vazão normalizada S (vazão × transmissão de um quadro)
+	Utilization float64                 // Fração do tempo em que uma mensagem circulou pelo anel (ρ)
+	LinkBusy    float64                 // Ocupação média dos enlaces (dados, respostas e token)
+	Wait        metrics.SummarySnapshot // Do enfileiramento à primeira transmissão
+	Service     metrics.SummarySnapshot // Da primeira transmissão ao ACK
+	Delay       metrics.SummarySnapshot // Do enfileiramento ao ACK
+	Rotation    metrics.SummarySnapshot // Volta do token na primeira estação
+	ModelWait   time.Duration           // Espera média pela fórmula (Model.Wait)
+	ModelDelay  time.Duration           // Atraso médio pela fórmula (Model.Delay)
+	Stable      bool                    // Indica se a fórmula prevê um anel estável nessa carga
+	Simulated   time.Duration           // Tempo virtual simulado
+	Events      uint64                  // Eventos executados
+	Elapsed     time.Duration           // Tempo real gasto na simulação
+}
+
+// Sweep simula o anel em cada carga da varredura, um anel novo por carga, e retorna os pontos na ordem das cargas
+// As cargas são simuladas em paralelo, uma por processador; progress, se informada, é chamada
+// ao fim de cada carga (uma chamada de cada vez, na ordem de término)
+func Sweep(cfg Config, opts Options, progress func(Point)) ([]Point, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, len(opts.Loads))
+	errs := make([]error, len(opts.Loads))
+	loads := make(chan int)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(opts.Loads)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range loads {
+				points[i], errs[i] = Simulate(cfg, opts.Loads[i], opts)
+				if errs[i] == nil && progress != nil {
+					mutex.Lock()
+					progress(points[i])
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range opts.Loads {
+		loads <- i
+	}
+	close(loads)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+// Simulate simula o anel com a carga oferecida (fração da saturação) e mede os tempos das mensagens
+//
+// Cada estação gera mensagens com chegadas de Poisson para destinos sorteados uniformemente entre
+// as demais (bench.Generator). Depois do aquecimento, as mensagens geradas em uma janela com
+// opts.Messages mensagens esperadas são acompanhadas até a confirmação; o tráfego continua
+// até lá, para que as últimas mensagens encontrem o anel na mesma carga
+func Simulate(cfg Config, load float64, opts Options) (Point, error) {
+	started := time.Now()
+	ring, err := NewRing(cfg)
+	if err != nil {
+		return Point{}, err
+	}
+	defer ring.Stop()
+
+	model := NewModel(cfg, opts.Size)
+	rate := load * model.SaturationRate()
+	window := time.Duration(float64(opts.Messages) / (rate * float64(cfg.Stations)) * float64(time.Second))
+	warmup := time.Duration(float64(window) * warmupShare)
+	// As mensagens da janela têm até várias janelas para serem confirmadas (mais, perto da saturação)
+	limit := warmup + 10*window
+
+	t := newTracker(ring.Sim, Epoch.Add(warmup), Epoch.Add(warmup+window), cfg.Stations, opts.Size, 4*opts.Messages)
+	for i, station := range ring.Stations {
+		station.Machine.OnEvent(t.handler(i))
+	}
+	if err := ring.Start(); err != nil {
+		return Point{}, err
+	}
+
+	for i, station := range ring.Stations {
+		destinations := make([]string, 0, cfg.Stations-1)
+		for _, other := range ring.Stations {
+			if other != station {
+				destinations = append(destinations, other.Name)
+			}
+		}
+		traffic := bench.Options{Pattern: bench.PatternPoisson, Rate: rate, Destinations: destinations}
+		generator := bench.NewGenerator(traffic, rand.New(rand.NewSource(cfg.Seed*7919+int64(i))))
+		t.generate(station.Machine, generator)
+	}
+
+	ring.Sim.RunUntil(Epoch.Add(warmup))
+	ring.ResetLinks()
+	ring.Sim.RunUntil(Epoch.Add(warmup + window))
+	linkBusy := ring.LinkUtilization()
+	for len(t.queued) > 0 && ring.Sim.Elapsed() < limit {
+		ring.Sim.RunFor(window / 10)
+	}
+
+	point := t.point(window)
+	point.Load = load
+	point.Rate = rate
+	point.Normalized = point.Throughput * model.Frame.Seconds()
+	point.LinkBusy = linkBusy
+	point.ModelWait, point.Stable = model.Wait(rate)
+	point.ModelDelay, _ = model.Delay(rate)
+	point.Simulated = ring.Sim.Elapsed()
+	point.Events = ring.Sim.Processed()
+	point.Elapsed = time.Since(started)
+	return point, nil
+}
+
+// tracker gera o tráfego e acompanha as mensagens da janela de medida pelos eventos das máquinas
+// Tudo é executado na goroutine do simulador: não precisa de mutex
+type tracker struct {
+	sim       *Simulator
+	from, to  time.Time                // Janela de medida
+	size      int                      // Tamanho do conteúdo das mensagens
+	counter   int                      // Mensagens geradas (identifica o conteúdo)
+	queued    map[string]*trackedEntry // Mensagens da janela aguardando confirmação, por conteúdo
+	offered   int
+	rejected  int
+	delivered int
+	acks      int           // Confirmações de qualquer mensagem durante a janela (vazão)
+	inFlight  []time.Time   // Transmissão do quadro em circulação de cada estação (zero = nenhum)
+	busy      time.Duration // Tempo de circulação de quadros de dados dentro da janela
+	wait      *metrics.Summary
+	service   *metrics.Summary
+	delay     *metrics.Summary
+	rotation  *metrics.Summary
+	lastToken time.Time
+}
+
+// trackedEntry é uma mensagem da janela de medida
+type trackedEntry struct {
+	queued time.Time
+	sent   time.Time
+}
+
+// newTracker cria o acompanhamento da janela de medida [from, to)
+// Os resumos guardam até samples observações
+func newTracker(sim *Simulator, from, to time.Time, stations, size, samples int) *tracker {
+	return &tracker{
+		sim:      sim,
+		from:     from,
+		to:       to,
+		size:     size,
+		queued:   make(map[string]*trackedEntry),
+		inFlight: make([]time.Time, stations),
+		wait:     metrics.NewSummary(samples),
+		service:  metrics.NewSummary(samples),
+		delay:    metrics.NewSummary(samples),
+		rotation: metrics.NewSummary(samples),
+	}
+}
+
+// inWindow verifica se o horário virtual atual está na janela de medida
+func (t *tracker) inWindow() bool {
+	now := t.sim.Now()
+	return !now.Before(t.from) && now.Before(t.to)
+}
+
+// generate agenda as chegadas de mensagens de uma estação
+func (t *tracker) generate(machine *network.Machine, generator *bench.Generator) {
+	// A primeira mensagem do gerador sai no instante zero: é descartada, para que as estações não comecem juntas
+	generator.Next()
+
+	var schedule func()
+	schedule = func() {
+		interval, destination := generator.Next()
+		t.sim.AfterFunc(interval, func() {
+			t.arrive(machine, destination)
+			schedule()
+		})
+	}
+	schedule()
+}
+
+// arrive enfileira uma mensagem gerada agora; as mensagens da janela passam a ser acompanhadas
+func (t *tracker) arrive(machine *network.Machine, destination string) {
+	t.counter++
+	content := fmt.Sprintf("m%d-", t.counter)
+	if len(content) < t.size {
+		content += strings.Repeat("x", t.size-len(content))
+	}
+
+	measured := t.inWindow()
+	if measured {
+		t.offered++
+		t.queued[content] = &trackedEntry{queued: t.sim.Now()}
+	}
+	if machine.QueueMessage(destination, content) != nil && measured {
+		delete(t.queued, content)
+		t.rejected++
+	}
+}
+
+// handler retorna a função de eventos da i-ésima estação; a primeira também mede a volta do token
+// Chamada no processamento da máquina: apenas atualiza as medidas
+func (t *tracker) handler(i int) network.EventHandler {
+	return func(event network.Event) {
+		switch event.Type {
+		case network.EventTokenReceived:
+			if i != 0 {
+				return
+			}
+			now := t.sim.Now()
+			if t.inWindow() && !t.lastToken.IsZero() {
+				t.rotation.Observe(now.Sub(t.lastToken).Seconds())
+			}
+			t.lastToken = now
+
+		case network.EventMessageSent:
+			t.inFlight[i] = t.sim.Now()
+			if entry, ok := t.queued[event.Message]; ok && entry.sent.IsZero() {
+				entry.sent = t.sim.Now()
+			}
+
+		case network.EventNAK, network.EventBusy, network.EventNotExists:
+			t.returned(i)
+
+		case network.EventACK:
+			t.returned(i)
+			if t.inWindow() {
+				t.acks++
+			}
+			entry, ok := t.queued[event.Message]
+			if !ok {
+				return
+			}
+			delete(t.queued, event.Message)
+			now := t.sim.Now()
+			t.delivered++
+			t.wait.Observe(entry.sent.Sub(entry.queued).Seconds())
+			t.service.Observe(now.Sub(entry.sent).Seconds())
+			t.delay.Observe(now.Sub(entry.queued).Seconds())
+		}
+	}
+}
+
+// returned soma à ocupação do anel a circulação do quadro da i-ésima estação, que acaba de voltar
+// Apenas a parte dentro da janela de medida é contada
+func (t *tracker) returned(i int) {
+	sent := t.inFlight[i]
+	if sent.IsZero() {
+		return
+	}
+	t.inFlight[i] = time.Time{}
+
+	start, end := sent, t.sim.Now()
+	if start.Before(t.from) {
+		start = t.from
+	}
+	if end.After(t.to) {
+		end = t.to
+	}
+	if end.After(start) {
+		t.busy += end.Sub(start)
+	}
+}
+
+// point monta o resultado com as medidas acumuladas
+func (t *tracker) point(window time.Duration) Point {
+	throughput := float64(t.acks) / window.Seconds()
+	utilization := t.busy.Seconds() / window.Seconds()
+	return Point{
+		Offered:     t.offered,
+		Rejected:    t.rejected,
+		Delivered:   t.delivered,
+		Pending:     len(t.queued),
+		Throughput:  throughput,
+		Utilization: utilization,
+		Wait:        t.wait.Snapshot(),
+		Service:     t.service.Snapshot(),
+		Delay:       t.delay.Snapshot(),
+		Rotation:    t.rotation.Snapshot(),
+	}
+}
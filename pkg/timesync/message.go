@@ -0,0 +1,82 @@
+package timesync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ring-network/pkg/message"
+)
+
+// Tipos de pacote de sincronização
+const (
+	KindPoll   = "consulta" // Coleta o horário de cada estação ao circular pelo anel
+	KindAdjust = "ajuste"   // Distribui as correções calculadas pelo monitor
+)
+
+// Entry associa uma estação a um valor em nanossegundos
+// Na consulta, o valor é o horário lido pela estação; no ajuste, a correção a aplicar
+type Entry struct {
+	Station string
+	Value   int64
+}
+
+// Message representa um pacote de sincronização de relógios
+// Formato: 5000;<rodada>:<tipo>:<monitor>:<estação>=<valor>,<estação>=<valor>,...
+// As entradas seguem a ordem do anel a partir do monitor
+type Message struct {
+	Round   string  // Identificador da rodada de sincronização
+	Kind    string  // Tipo do pacote
+	Monitor string  // Estação que coordena a rodada
+	Entries []Entry // Leituras ou correções por estação
+}
+
+// Encode converte a mensagem para o formato do pacote
+func (msg Message) Encode() string {
+	entries := make([]string, len(msg.Entries))
+	for i, entry := range msg.Entries {
+		entries[i] = fmt.Sprintf("%s=%d", entry.Station, entry.Value)
+	}
+	return fmt.Sprintf("%s;%s:%s:%s:%s", message.TimeSyncPacket,
+		msg.Round, msg.Kind, msg.Monitor, strings.Join(entries, ","))
+}
+
+// Entry retorna o valor registrado para uma estação
+func (msg Message) Entry(station string) (int64, bool) {
+	for _, entry := range msg.Entries {
+		if entry.Station == station {
+			return entry.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Decode analisa um pacote de sincronização recebido
+func Decode(data string) (Message, error) {
+	_, body, found := strings.Cut(data, ";")
+	if !found || message.PacketType(data) != message.TimeSyncPacket {
+		return Message{}, fmt.Errorf("não é um pacote de sincronização válido")
+	}
+
+	parts := strings.SplitN(body, ":", 4)
+	if len(parts) != 4 {
+		return Message{}, fmt.Errorf("formato de pacote de sincronização inválido: esperado 4 partes, obtido %d", len(parts))
+	}
+
+	msg := Message{Round: parts[0], Kind: parts[1], Monitor: parts[2]}
+	if parts[3] == "" {
+		return msg, nil
+	}
+	for _, field := range strings.Split(parts[3], ",") {
+		station, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Message{}, fmt.Errorf("entrada de sincronização inválida: %s", field)
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Message{}, fmt.Errorf("valor inválido no pacote de sincronização: %v", err)
+		}
+		msg.Entries = append(msg.Entries, Entry{Station: station, Value: n})
+	}
+	return msg, nil
+}
@@ -0,0 +1,278 @@
+package timesync
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ring-network/pkg/message"
+	"ring-network/pkg/network"
+)
+
+// DefaultMaxDeviation é o desvio máximo em relação à mediana para que uma leitura entre na média
+// Leituras mais distantes (relógios defeituosos) são ignoradas no cálculo, mas também recebem correção
+const DefaultMaxDeviation = 2 * time.Second
+
+// Result resume uma rodada de sincronização concluída no monitor
+type Result struct {
+	Round       string                   // Identificador da rodada
+	Stations    []string                 // Estações na ordem do anel, a partir do monitor
+	RTT         time.Duration            // Tempo de circulação da consulta
+	Offsets     map[string]time.Duration // Diferença estimada de cada relógio em relação ao monitor
+	Excluded    []string                 // Estações ignoradas no cálculo da média
+	Average     time.Duration            // Média das diferenças aceitas
+	Corrections map[string]time.Duration // Correção enviada a cada estação
+}
+
+// String retorna uma representação em string do resultado
+func (r Result) String() string {
+	corrections := make([]string, len(r.Stations))
+	for i, station := range r.Stations {
+		corrections[i] = fmt.Sprintf("%s %+v", station, r.Corrections[station].Round(time.Microsecond))
+	}
+	text := fmt.Sprintf("rodada %s: RTT=%v, média=%+v, correções: %s", r.Round,
+		r.RTT.Round(time.Microsecond), r.Average.Round(time.Microsecond), strings.Join(corrections, ", "))
+	if len(r.Excluded) > 0 {
+		text += fmt.Sprintf(" (ignoradas na média: %s)", strings.Join(r.Excluded, ", "))
+	}
+	return text
+}
+
+// round guarda o estado de uma rodada iniciada por esta estação
+type round struct {
+	started time.Time // Horário da estação ao enviar a consulta
+	sent    time.Time // Relógio do sistema ao enviar a consulta, para medir o RTT
+	done    chan struct{}
+	result  *Result
+}
+
+// Service sincroniza os relógios das estações com o algoritmo de Berkeley
+// A estação que inicia a rodada atua como monitor: uma consulta circula pelo anel
+// coletando o horário de cada estação, o monitor calcula a média das diferenças e
+// um segundo pacote distribui a correção de cada estação
+type Service struct {
+	machine      *network.Machine
+	rounds       map[string]*round
+	results      []Result
+	counter      int
+	maxDeviation time.Duration
+	mutex        sync.Mutex
+}
+
+// NewService cria o serviço de sincronização e registra o tratamento dos pacotes na máquina
+// Com interval maior que zero, esta estação inicia rodadas periodicamente como monitor
+func NewService(machine *network.Machine, interval time.Duration) (*Service, error) {
+	s := &Service{
+		machine:      machine,
+		rounds:       make(map[string]*round),
+		maxDeviation: DefaultMaxDeviation,
+	}
+	if err := machine.RegisterFrameHandler(message.TimeSyncPacket, s.handleFrame); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go s.periodic(interval)
+	}
+	return s, nil
+}
+
+// SetMaxDeviation define o desvio máximo em relação à mediana aceito na média
+func (s *Service) SetMaxDeviation(deviation time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.maxDeviation = deviation
+}
+
+// Sync executa uma rodada de sincronização com esta estação como monitor
+func (s *Service) Sync(ctx context.Context) (Result, error) {
+	s.mutex.Lock()
+	s.counter++
+	id := fmt.Sprintf("%s-%d", s.machine.Name(), s.counter)
+	r := &round{
+		started: s.machine.Now(),
+		sent:    time.Now(),
+		done:    make(chan struct{}),
+	}
+	s.rounds[id] = r
+	s.mutex.Unlock()
+
+	s.machine.Logf("Sincronização %s iniciada", id)
+	poll := Message{Round: id, Kind: KindPoll, Monitor: s.machine.Name()}
+	if err := s.machine.SendFrame(poll.Encode()); err != nil {
+		s.forget(id)
+		return Result{}, fmt.Errorf("erro ao enviar consulta de sincronização: %v", err)
+	}
+
+	select {
+	case <-r.done:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return *r.result, nil
+	case <-ctx.Done():
+		s.forget(id)
+		return Result{}, ctx.Err()
+	}
+}
+
+// Results retorna os resultados das rodadas concluídas por este monitor
+func (s *Service) Results() []Result {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Result(nil), s.results...)
+}
+
+// periodic inicia rodadas de sincronização até a máquina ser parada
+func (s *Service) periodic(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.machine.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if _, err := s.Sync(ctx); err != nil {
+				s.machine.Log(slog.LevelWarn, "Sincronização não concluída: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// forget descarta uma rodada que não será concluída
+func (s *Service) forget(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.rounds, id)
+}
+
+// handleFrame processa um pacote de sincronização recebido
+func (s *Service) handleFrame(data string) {
+	msg, err := Decode(data)
+	if err != nil {
+		s.machine.Log(slog.LevelError, "Erro ao parsear pacote de sincronização: %v", err)
+		return
+	}
+
+	name := s.machine.Name()
+	if msg.Monitor == name {
+		s.complete(msg)
+		return
+	}
+
+	switch msg.Kind {
+	case KindPoll:
+		// Registra o horário desta estação e repassa a consulta
+		if _, seen := msg.Entry(name); !seen {
+			msg.Entries = append(msg.Entries, Entry{Station: name, Value: s.machine.Now().UnixNano()})
+		}
+
+	case KindAdjust:
+		// Aplica a correção calculada pelo monitor e repassa o ajuste
+		if value, ok := msg.Entry(name); ok {
+			correction := time.Duration(value)
+			s.machine.GetWallClock().Adjust(correction)
+			s.machine.Logf("Sincronização %s: relógio corrigido em %+v", msg.Round, correction)
+		}
+
+	default:
+		s.machine.Log(slog.LevelWarn, "Tipo de pacote de sincronização desconhecido: %s", msg.Kind)
+		return
+	}
+
+	if err := s.machine.SendFrame(msg.Encode()); err != nil {
+		s.machine.Log(slog.LevelError, "Sincronização %s: erro ao repassar pacote: %v", msg.Round, err)
+	}
+}
+
+// complete trata um pacote que retornou ao monitor após percorrer o anel
+func (s *Service) complete(msg Message) {
+	s.mutex.Lock()
+	r, ok := s.rounds[msg.Round]
+	if !ok || (msg.Kind == KindPoll) != (r.result == nil) {
+		s.mutex.Unlock()
+		s.machine.Log(slog.LevelWarn, "Pacote de sincronização desconhecido, repetido ou cancelado: %s", msg.Round)
+		return
+	}
+
+	if msg.Kind == KindAdjust {
+		// O ajuste percorreu o anel: todas as estações aplicaram a correção
+		delete(s.rounds, msg.Round)
+		s.results = append(s.results, *r.result)
+		close(r.done)
+		s.mutex.Unlock()
+		s.machine.Logf("Sincronização %s concluída: %s", msg.Round, r.result)
+		return
+	}
+
+	result := s.compute(msg, r)
+	r.result = &result
+	s.mutex.Unlock()
+
+	// O monitor aplica sua própria correção e distribui as demais
+	s.machine.GetWallClock().Adjust(result.Corrections[s.machine.Name()])
+	adjust := Message{Round: msg.Round, Kind: KindAdjust, Monitor: msg.Monitor}
+	for _, station := range result.Stations[1:] {
+		adjust.Entries = append(adjust.Entries, Entry{Station: station, Value: int64(result.Corrections[station])})
+	}
+	if err := s.machine.SendFrame(adjust.Encode()); err != nil {
+		s.machine.Log(slog.LevelError, "Sincronização %s: erro ao enviar ajuste: %v", msg.Round, err)
+	}
+}
+
+// compute aplica o algoritmo de Berkeley às leituras coletadas pela consulta
+// Deve ser chamado com o mutex já adquirido
+func (s *Service) compute(msg Message, r *round) Result {
+	rtt := time.Since(r.sent)
+	result := Result{
+		Round:       msg.Round,
+		Stations:    []string{msg.Monitor},
+		RTT:         rtt,
+		Offsets:     map[string]time.Duration{msg.Monitor: 0},
+		Corrections: make(map[string]time.Duration),
+	}
+
+	// Compensação do RTT: a consulta percorre len(entradas)+1 enlaces; supondo o mesmo
+	// atraso em cada enlace, a i-ésima estação leu seu relógio i enlaces após o envio
+	hop := rtt / time.Duration(len(msg.Entries)+1)
+	for i, entry := range msg.Entries {
+		expected := r.started.Add(hop * time.Duration(i+1))
+		result.Stations = append(result.Stations, entry.Station)
+		result.Offsets[entry.Station] = time.Unix(0, entry.Value).Sub(expected)
+	}
+
+	// Leituras muito distantes da mediana não entram na média
+	offsets := make([]time.Duration, 0, len(result.Offsets))
+	for _, offset := range result.Offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	median := offsets[len(offsets)/2]
+
+	var sum time.Duration
+	accepted := 0
+	for _, station := range result.Stations {
+		deviation := result.Offsets[station] - median
+		if deviation > s.maxDeviation || deviation < -s.maxDeviation {
+			result.Excluded = append(result.Excluded, station)
+			continue
+		}
+		sum += result.Offsets[station]
+		accepted++
+	}
+	result.Average = sum / time.Duration(accepted)
+
+	// Cada estação recebe a correção que a leva à média, inclusive as ignoradas
+	for _, station := range result.Stations {
+		result.Corrections[station] = result.Average - result.Offsets[station]
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+-- Dissector do Wireshark para as capturas da rede em anel (opção captura=<arquivo.pcapng>)
+--
+-- Instalação: copie este arquivo para a pasta de plugins Lua pessoais
+-- (Ajuda > Sobre o Wireshark > Pastas > Plugins Lua pessoais) e reabra a captura.
+-- Também funciona com o tshark: tshark -X lua_script:ring_network.lua -r alice.pcapng
+--
+-- As capturas usam o tipo de enlace LINKTYPE_USER0 (147). Cada pacote tem o formato:
+--
+--   0      1 byte   versão do cabeçalho (1)
+--   1      1 byte   direção: 0 = recebido, 1 = enviado
+--   2      2 bytes  tamanho N do endereço do par (big-endian)
+--   4      N bytes  endereço do par, em texto (ex: "127.0.0.1:6001")
+--   4+N    ...      quadro, como transmitido
+--
+-- O quadro é texto: <tipo>|chave=valor|...;<corpo>
+--   tipo 1000  token (sem corpo; atributo seq na difusão ordenada)
+--   tipo 2000  dados: origem:destino:controle:crc:mensagem
+--   tipo 3000  eleição de líder
+--   tipo 4000  marcador de snapshot
+--   tipo 5000  sincronização de relógios
+--   tipo 6000  censo (descoberta das estações)
+--   tipo 7000  ping: id:origem:destino:saltos:saltos até o destino
+--   tipo 8000  trace: como o ping, seguido do caminho estação@horário,...
+-- Atributos do cabeçalho: lc (relógio de Lamport), vc (relógio vetorial), seq (sequência)
+
+local ring = Proto("ring", "Rede em Anel")
+
+local directions = { [0] = "recebido", [1] = "enviado" }
+
+local packet_names = {
+    ["1000"] = "TOKEN",
+    ["2000"] = "DADOS",
+    ["3000"] = "ELEIÇÃO",
+    ["4000"] = "SNAPSHOT",
+    ["5000"] = "SINCRONIZAÇÃO",
+    ["6000"] = "CENSO",
+    ["7000"] = "PING",
+    ["8000"] = "TRACE",
+}
+
+local f = ring.fields
+f.version     = ProtoField.uint8("ring.versao", "Versão do cabeçalho")
+f.direction   = ProtoField.uint8("ring.direcao", "Direção", base.DEC, directions)
+f.peer        = ProtoField.string("ring.par", "Endereço do par")
+f.frame       = ProtoField.string("ring.quadro", "Quadro")
+f.type        = ProtoField.string("ring.tipo", "Tipo")
+f.lamport     = ProtoField.uint32("ring.lc", "Relógio de Lamport")
+f.vector      = ProtoField.string("ring.vc", "Relógio vetorial")
+f.seq         = ProtoField.uint32("ring.seq", "Sequência")
+f.attr        = ProtoField.string("ring.atributo", "Atributo")
+f.body        = ProtoField.string("ring.corpo", "Corpo")
+f.origin      = ProtoField.string("ring.origem", "Origem")
+f.destination = ProtoField.string("ring.destino", "Destino")
+f.control     = ProtoField.string("ring.controle", "Controle")
+f.crc         = ProtoField.string("ring.crc", "CRC32")
+f.message     = ProtoField.string("ring.mensagem", "Mensagem")
+f.id          = ProtoField.string("ring.id", "Identificador")
+f.hops        = ProtoField.uint32("ring.saltos", "Saltos")
+f.reached     = ProtoField.uint32("ring.saltos_destino", "Saltos até o destino")
+f.path        = ProtoField.string("ring.caminho", "Caminho")
+
+-- split divide s pelo separador (um caractere), retornando as partes e as posições iniciais (base 0)
+local function split(s, sep, limit)
+    local parts, starts = {}, {}
+    local start = 1
+    while true do
+        if limit and #parts == limit - 1 then
+            break
+        end
+        local i = string.find(s, sep, start, true)
+        if not i then
+            break
+        end
+        table.insert(parts, string.sub(s, start, i - 1))
+        table.insert(starts, start - 1)
+        start = i + 1
+    end
+    table.insert(parts, string.sub(s, start))
+    table.insert(starts, start - 1)
+    return parts, starts
+end
+
+-- add_field acrescenta um campo com o trecho correspondente do pacote (campos vazios não têm trecho)
+local function add_field(tree, field, buffer, offset, length, value)
+    if length > 0 then
+        return tree:add(field, buffer(offset, length), value)
+    end
+    return tree:add(field, value)
+end
+
+function ring.dissector(buffer, pinfo, tree)
+    if buffer:len() < 4 then
+        return 0
+    end
+
+    pinfo.cols.protocol = "RING"
+    local subtree = tree:add(ring, buffer(), "Rede em Anel")
+
+    local direction = buffer(1, 1):uint()
+    local peer_len = buffer(2, 2):uint()
+    subtree:add(f.version, buffer(0, 1))
+    subtree:add(f.direction, buffer(1, 1))
+
+    local peer = ""
+    if peer_len > 0 then
+        peer = buffer(4, peer_len):string()
+        subtree:add(f.peer, buffer(4, peer_len))
+    end
+    if direction == 1 then
+        pinfo.cols.src = "local"
+        pinfo.cols.dst = peer
+    else
+        pinfo.cols.src = peer
+        pinfo.cols.dst = "local"
+    end
+
+    local offset = 4 + peer_len
+    if offset >= buffer:len() then
+        return buffer:len()
+    end
+    local frame_range = buffer(offset)
+    local frame = frame_range:string()
+    local frame_tree = subtree:add(f.frame, frame_range)
+
+    -- Cabeçalho (tipo e atributos) e corpo
+    local header, body = frame, nil
+    local semicolon = string.find(frame, ";", 1, true)
+    if semicolon then
+        header = string.sub(frame, 1, semicolon - 1)
+        body = string.sub(frame, semicolon + 1)
+    end
+
+    local fields, starts = split(header, "|")
+    local packet_type = fields[1]
+    local name = packet_names[packet_type] or ("tipo " .. packet_type)
+    add_field(frame_tree, f.type, buffer, offset, #packet_type, packet_type):append_text(" (" .. name .. ")")
+
+    local info = name
+    for i = 2, #fields do
+        local start, length = offset + starts[i], #fields[i]
+        local key, value = string.match(fields[i], "^([^=]*)=(.*)$")
+        if key == "lc" then
+            add_field(frame_tree, f.lamport, buffer, start, length, tonumber(value) or 0)
+        elseif key == "vc" then
+            add_field(frame_tree, f.vector, buffer, start, length, value)
+        elseif key == "seq" then
+            add_field(frame_tree, f.seq, buffer, start, length, tonumber(value) or 0)
+            info = info .. " seq=" .. value
+        else
+            add_field(frame_tree, f.attr, buffer, start, length, fields[i])
+        end
+    end
+
+    if body then
+        local body_offset = offset + semicolon
+        local body_tree = add_field(frame_tree, f.body, buffer, body_offset, #body, body)
+
+        if packet_type == "2000" then
+            local parts, part_starts = split(body, ":", 5)
+            local labels = { f.origin, f.destination, f.control, f.crc, f.message }
+            for i = 1, #parts do
+                add_field(body_tree, labels[i], buffer, body_offset + part_starts[i], #parts[i], parts[i])
+            end
+            if #parts == 5 then
+                info = string.format("%s %s→%s [%s] \"%s\"", name, parts[1], parts[2], parts[3], parts[5])
+            end
+        elseif packet_type == "7000" or packet_type == "8000" then
+            local parts, part_starts = split(body, ":", 6)
+            local labels = { f.id, f.origin, f.destination, f.hops, f.reached, f.path }
+            for i = 1, #parts do
+                local value = parts[i]
+                if i == 4 or i == 5 then
+                    value = tonumber(value) or 0
+                end
+                add_field(body_tree, labels[i], buffer, body_offset + part_starts[i], #parts[i], value)
+            end
+            if #parts >= 5 then
+                info = string.format("%s %s→%s saltos=%s", name, parts[2], parts[3], parts[4])
+                if parts[5] ~= "0" then
+                    info = info .. " (destino em " .. parts[5] .. ")"
+                end
+            end
+        else
+            -- Pacotes de controle: os primeiros campos identificam a execução
+            local parts = split(body, ":", 4)
+            info = info .. " " .. table.concat(parts, ":", 1, math.min(#parts, 3))
+        end
+    end
+
+    pinfo.cols.info = info
+    return buffer:len()
+end
+
+DissectorTable.get("wtap_encap"):add(wtap.USER0, ring)